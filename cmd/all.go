@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/NETWAYS/go-check"
+	"github.com/NETWAYS/go-check/result"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// allFlagSeparator separates the name of a sub command from the name of its flags in the "all" sub command
+const allFlagSeparator = "-"
+
+var AllChecks = []string{"memory", "load", "filesystem", "netdev"}
+
+type registeredCheck struct {
+	name string
+	fn   checkFunction
+}
+
+// registeredChecks contains all the sub commands which can be run by the "all" sub command
+var registeredChecks = make([]registeredCheck, 0)
+
+var allCmd = &cobra.Command{
+	Use:   "all",
+	Short: "Submodule to run several of the other submodules in one execution",
+	Long: `Submodule to run several of the other submodules in one execution.
+The flags of every submodule are available with the name of the submodule as prefix,
+e.g. --load-load1-warning or --netdev-include-interface-name`,
+	Example: `./check_system_basics all --checks memory,load --load-load1-warning 4
+[OK] - states: ok=2
+\_ [OK] memory
+    \_ [OK] RAM
+        \_ [OK] Available Memory (23 GiB/31 GiB, 74.36%)
+        \_ [OK] Free Memory (16 GiB/31 GiB, 49.95%)
+        \_ [OK] Used Memory (6.1 GiB/31 GiB, 19.57%)
+    \_ [OK] Swap Usage 0.00% (0 B / 36 GiB)
+\_ [OK] load
+    \_ [OK] 1 minute average: 0.10
+    \_ [OK] 5 minute average: 0.21
+    \_ [OK] 15 minute average: 0.25
+|available_memory=24856633344B;;;0;33427595264 free_memory=16696102912B;;;0;33427595264 used_memory=6542696448B;;;0;33427595264 swap_used=0B;;;0;38654701568 load1=0.1;4;;0 load5=0.21;;;0 load15=0.25;;;0`,
+	Run: runCheck(allCheck),
+}
+
+func init() {
+	rootCmd.AddCommand(allCmd)
+
+	fs := allCmd.Flags()
+
+	fs.StringSliceVar(&AllChecks, "checks", AllChecks,
+		"The submodules to run (may be repeated). E.g. 'memory,load', 'netdev'")
+
+	fs.SortFlags = false
+}
+
+// registerCheck makes a sub command available in the "all" sub command. Its flags are added to
+// the "all" sub command with the name of the sub command as prefix and share the same values,
+// therefore this must be called after the flags of the sub command are defined
+func registerCheck(cmd *cobra.Command, fn checkFunction) {
+	registeredChecks = append(registeredChecks, registeredCheck{name: cmd.Name(), fn: fn})
+
+	fs := allCmd.Flags()
+
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		fs.AddFlag(&pflag.Flag{
+			Name:        cmd.Name() + allFlagSeparator + flag.Name,
+			Usage:       flag.Usage,
+			Value:       flag.Value,
+			DefValue:    flag.DefValue,
+			NoOptDefVal: flag.NoOptDefVal,
+		})
+	})
+}
+
+func allCheck(cmd *cobra.Command) ([]*result.PartialResult, error) {
+	names := make([]string, len(registeredChecks))
+
+	for i := range registeredChecks {
+		names[i] = registeredChecks[i].name
+	}
+
+	results := make([]*result.PartialResult, 0, len(AllChecks))
+
+	// The perfdata labels of the previous checks
+	labels := make(map[string]bool)
+
+	for _, name := range AllChecks {
+		idx := slices.Index(names, name)
+		if idx == -1 {
+			return nil, fmt.Errorf("unknown check %q, available are: %s", name, strings.Join(names, ", "))
+		}
+
+		partial := combineCheckResults(cmd, &registeredChecks[idx])
+		uniquePerfdata(partial, name, labels)
+
+		results = append(results, partial)
+	}
+
+	return results, nil
+}
+
+// uniquePerfdata prefixes the perfdata labels of a check with its name if they were already reported by one
+// of the previous checks, which are contained in labels, since the monitoring system would mix up the values otherwise
+func uniquePerfdata(partial *result.PartialResult, name string, labels map[string]bool) {
+	// The same perfdata may be added to several results
+	seen := make(map[*check.Perfdata]bool)
+	current := make([]string, 0)
+
+	for _, pd := range perfdataOf(partial) {
+		if seen[pd] {
+			continue
+		}
+
+		seen[pd] = true
+
+		if labels[pd.Label] {
+			pd.Label = name + "_" + pd.Label
+		}
+
+		current = append(current, pd.Label)
+	}
+
+	for _, label := range current {
+		labels[label] = true
+	}
+}
+
+// perfdataOf returns the perfdata of the partial result and all its subchecks, so changing them changes the output.
+// go-check does not export them, therefore they are read by reflection
+func perfdataOf(partial *result.PartialResult) []*check.Perfdata {
+	value := reflect.ValueOf(partial).Elem()
+	perfdata := value.FieldByName("perfdata")
+	subchecks := value.FieldByName("partialResults")
+
+	list := make([]*check.Perfdata, 0, perfdata.Len())
+
+	for i := range perfdata.Len() {
+		list = append(list, (*check.Perfdata)(perfdata.Index(i).UnsafePointer()))
+	}
+
+	for i := range subchecks.Len() {
+		list = append(list, perfdataOf((*result.PartialResult)(subchecks.Index(i).UnsafePointer()))...)
+	}
+
+	return list
+}
+
+// combineCheckResults runs a check and collects its results below one result with the name of the check.
+// An error of a single check does not abort the others, but results in an UNKNOWN state for this check
+func combineCheckResults(cmd *cobra.Command, rc *registeredCheck) *result.PartialResult {
+	partial := result.NewPartialResult()
+	partial.SetDefaultState(check.OK)
+
+	results, err := rc.fn(cmd)
+	if err != nil {
+		partial.SetState(check.Unknown)
+		partial.SetOutput(fmt.Sprintf("%s: %s", rc.name, err))
+
+		return partial
+	}
+
+	partial.SetOutput(rc.name)
+
+	for i := range results {
+		partial.AddSubcheck(results[i])
+	}
+
+	return partial
+}
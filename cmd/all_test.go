@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/NETWAYS/go-check"
+	"github.com/NETWAYS/go-check/result"
+	"github.com/spf13/cobra"
+)
+
+func TestAllNamespacedFlags(t *testing.T) {
+	for _, name := range []string{"load-load1-warning", "memory-memory-available-warning", "netdev-include-interface-name"} {
+		if allCmd.Flags().Lookup(name) == nil {
+			t.Fatalf("expected flag %v in the all sub command", name)
+		}
+	}
+
+	err := allCmd.Flags().Set("load-load1-warning", "5")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	defer func() { LoadConfig.Load1Th.Warn.IsSet = false }()
+
+	if !LoadConfig.Load1Th.Warn.IsSet || LoadConfig.Load1Th.Warn.Th.Upper != 5 {
+		t.Fatalf("expected the namespaced flag to set the load threshold, got %v", LoadConfig.Load1Th.Warn)
+	}
+}
+
+func TestCombineCheckResults(t *testing.T) {
+	rc := registeredCheck{
+		name: "test",
+		fn: func(_ *cobra.Command) ([]*result.PartialResult, error) {
+			partial := result.NewPartialResult()
+			partial.SetState(check.Warning)
+
+			return []*result.PartialResult{partial}, nil
+		},
+	}
+
+	res := combineCheckResults(allCmd, &rc)
+
+	if check.Warning != res.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.Warning, res.GetStatus())
+	}
+
+	rc.fn = func(_ *cobra.Command) ([]*result.PartialResult, error) {
+		return nil, errors.New("broken")
+	}
+
+	res = combineCheckResults(allCmd, &rc)
+
+	if res.String() != "[UNKNOWN] test: broken" {
+		t.Fatalf("expected %v, got %v", "[UNKNOWN] test: broken", res.String())
+	}
+}
+
+func TestAllUnknownCheck(t *testing.T) {
+	previous := AllChecks
+	defer func() { AllChecks = previous }()
+
+	AllChecks = []string{"nonexistent"}
+
+	_, err := allCheck(allCmd)
+	if err == nil {
+		t.Fatalf("expected an error for an unknown check")
+	}
+}
+
+func TestAllDuplicatePerfdata(t *testing.T) {
+	previousChecks, previousRegistered := AllChecks, registeredChecks
+	defer func() { AllChecks, registeredChecks = previousChecks, previousRegistered }()
+
+	withLabels := func(labels ...string) checkFunction {
+		return func(_ *cobra.Command) ([]*result.PartialResult, error) {
+			partial := result.NewPartialResult()
+			partial.SetState(check.OK)
+
+			for _, label := range labels {
+				partial.AddPerfdata(&check.Perfdata{Label: label, Value: 1})
+			}
+
+			return []*result.PartialResult{partial}, nil
+		}
+	}
+
+	registeredChecks = []registeredCheck{
+		{name: "first", fn: withLabels("used", "mount point")},
+		{name: "second", fn: withLabels("free")},
+		{name: "third", fn: withLabels("mount point")},
+	}
+	AllChecks = []string{"first", "second", "third"}
+
+	results, err := allCheck(allCmd)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// The colliding label is prefixed, the state of the check is kept
+	for i, expected := range [][]string{{"used", "mount point"}, {"free"}, {"third_mount point"}} {
+		if results[i].GetStatus() != check.OK {
+			t.Fatalf("expected %v for %s, got %v", check.OK, AllChecks[i], results[i].GetStatus())
+		}
+
+		perfdata := perfdataOf(results[i])
+
+		labels := make([]string, len(perfdata))
+		for j := range perfdata {
+			labels[j] = perfdata[j].Label
+		}
+
+		if !slices.Equal(labels, expected) {
+			t.Fatalf("expected %v for %s, got %v", expected, AllChecks[i], labels)
+		}
+	}
+}
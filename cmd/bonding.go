@@ -0,0 +1,260 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/NETWAYS/check_system_basics/internal/bonding"
+	"github.com/NETWAYS/check_system_basics/internal/common/thresholds"
+	"github.com/NETWAYS/go-check"
+	"github.com/NETWAYS/go-check/result"
+	"github.com/spf13/cobra"
+)
+
+var BondingConfig bonding.CheckConfig
+
+var bondingCmd = &cobra.Command{
+	Use:   "bonding",
+	Short: "Submodule to check the health of the bonding interfaces and their slaves",
+	Long: `Submodule to check the health of the bonding interfaces and their slaves.
+The operational state of a bond usually stays up as long as one slave is working,
+therefore the slaves are checked individually.
+Only bonding interfaces are covered, team interfaces (teamd) are not supported.`,
+	Example: `./check_system_basics bonding --critical-slaves-up 2:
+[CRITICAL] - states: critical=1
+\_ [CRITICAL] bond0 (active-backup), active slave: eno1, 1/2 slaves up
+    \_ [OK] eno1: MII status up, 10000 Mbps full duplex, 0 link failures
+    \_ [WARNING] eno2: MII status down, 3 link failures
+|bond0_slaves_up=1;;2:;0;2 bond0_eno1_link_failures=0c bond0_eno2_link_failures=3c`,
+	Run: runCheck(bondingCheck),
+}
+
+func init() {
+	rootCmd.AddCommand(bondingCmd)
+
+	fs := bondingCmd.Flags()
+
+	fs.StringSliceVar(&BondingConfig.Filters.IncludeBondNames, "include-bond-name", nil,
+		"Explicitly include only bonds whose names match this regexp regex (may be repeated). E.g. 'bond0', '^bond.*'")
+	fs.StringSliceVar(&BondingConfig.Filters.ExcludeBondNames, "exclude-bond-name", nil,
+		"Ignore all bonds where the bond name matches this regexp regex (may be repeated). E.g. 'bond0', '^bond.*'")
+	fs.StringVar(&BondingConfig.ActiveSlaveChangedState, "active-slave-changed-state", check.WarningString,
+		"The state if the active slave of a bond changed since the previous execution")
+
+	bondingThresholds := []thresholds.ThresholdOption{
+		{
+			Th:          &BondingConfig.SlavesUp.Warn,
+			FlagString:  "warning-slaves-up",
+			Description: "Warning threshold for the number of slaves which are up (per bond). E.g. '2:' to alert if less than two slaves are up. Without thresholds min_links of the bond is the critical threshold",
+		},
+		{
+			Th:          &BondingConfig.SlavesUp.Crit,
+			FlagString:  "critical-slaves-up",
+			Description: "Critical threshold for the number of slaves which are up (per bond). E.g. '1:' to alert if no slave is up",
+		},
+		{
+			Th:          &BondingConfig.LinkFailures.Warn,
+			FlagString:  "warning-link-failures",
+			Description: "Warning threshold for the link failures of a slave since the previous execution",
+		},
+		{
+			Th:          &BondingConfig.LinkFailures.Crit,
+			FlagString:  "critical-link-failures",
+			Description: "Critical threshold for the link failures of a slave since the previous execution",
+		},
+	}
+
+	thresholds.AddFlags(fs, &bondingThresholds)
+
+	fs.SortFlags = false
+
+	registerCheck(bondingCmd, bondingCheck)
+}
+
+func bondingCheck(cmd *cobra.Command) ([]*result.PartialResult, error) {
+	changedState, err := check.NewStatusFromString(BondingConfig.ActiveSlaveChangedState)
+	if err != nil {
+		return nil, err
+	}
+
+	bonds, err := bonding.GetAllBonds()
+	if err != nil {
+		return nil, err
+	}
+
+	bonds, err = bonding.FilterBonds(bonds, &BondingConfig.Filters)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bonds) == 0 {
+		noBonds := result.NewPartialResult()
+		noBonds.SetState(check.Unknown)
+		noBonds.SetOutput("No bonding interfaces found")
+
+		return []*result.PartialResult{noBonds}, nil
+	}
+
+	// If the state can not be opened, the bonds and their slaves are still checked and only the active slave
+	// changes and the link failures since the previous execution are UNKNOWN
+	store, storeErr := openStateStore(cmd, "bonding")
+
+	results := make([]*result.PartialResult, 0, len(bonds))
+
+	for i := range bonds {
+		var previousActiveSlave *string
+
+		// Link failures are only known for slaves which were present in the previous execution as well
+		linkFailures := make(map[string]uint64)
+
+		if store != nil {
+			activeSlaveKey := bonds[i].Name + "_active_slave"
+
+			if previous, ok := store.PreviousString(activeSlaveKey); ok {
+				previousActiveSlave = &previous
+			}
+
+			store.SetString(activeSlaveKey, bonds[i].ActiveSlave)
+
+			for _, slave := range bonds[i].Slaves {
+				delta, err := store.Delta(bonds[i].Name+"_"+slave.Name+"_link_failures", slave.LinkFailureCount)
+				if err == nil {
+					linkFailures[slave.Name] = delta
+				}
+			}
+		}
+
+		bondResult := computeBond(&bonds[i], previousActiveSlave, linkFailures, changedState, &BondingConfig)
+
+		if storeErr != nil {
+			noState := result.NewPartialResult()
+			noState.SetState(check.Unknown)
+			noState.SetOutput("Active slave changes and link failures since the previous execution not available: " + storeErr.Error())
+			bondResult.AddSubcheck(noState)
+
+			bondResult.SetState(check.WorstState(bondResult.GetStatus(), check.Unknown))
+		}
+
+		results = append(results, bondResult)
+	}
+
+	if store != nil {
+		err = store.Close()
+		if err != nil {
+			results = append(results, stateNotSaved(err))
+		}
+	}
+
+	return results, nil
+}
+
+// computeBond evaluates a bond and its slaves. previousActiveSlave is nil if the active slave of the previous
+// execution is not known, linkFailures contains the link failures since the previous execution per slave
+func computeBond(bond *bonding.Bond, previousActiveSlave *string, linkFailures map[string]uint64,
+	changedState check.Status, config *bonding.CheckConfig) *result.PartialResult {
+	bondResult := result.NewPartialResult()
+	bondResult.SetDefaultState(check.OK)
+
+	slavesUp := bond.SlavesUp()
+
+	output := fmt.Sprintf("%s (%s)", bond.Name, bond.Mode)
+
+	if bond.ActiveSlave != "" {
+		output += ", active slave: " + bond.ActiveSlave
+	}
+
+	output += fmt.Sprintf(", %d/%d slaves up", slavesUp, len(bond.Slaves))
+
+	pdSlaves := check.Perfdata{
+		Label: bond.Name + "_slaves_up",
+		Value: slavesUp,
+		Min:   0,
+		Max:   len(bond.Slaves),
+	}
+
+	slavesUpThresholds := config.SlavesUp
+
+	// The bond takes its carrier down with fewer slaves than min_links, which is the default critical threshold
+	if !slavesUpThresholds.IsSet() && bond.MinLinks > 0 {
+		slavesUpThresholds.Crit = thresholds.ThresholdWrapper{
+			Th:    check.Threshold{Lower: float64(bond.MinLinks), Upper: check.PosInf},
+			IsSet: true,
+		}
+	}
+
+	bondState := slavesUpThresholds.Evaluate(float64(slavesUp), &pdSlaves)
+	if bondState != check.OK {
+		output += " violates threshold"
+	}
+
+	bondResult.AddPerfdata(&pdSlaves)
+
+	if bond.MIIStatus != "" && bond.MIIStatus != bonding.MIIStatusUp {
+		bondState = check.Critical
+		output += ", MII status " + bond.MIIStatus
+	}
+
+	if previousActiveSlave != nil && *previousActiveSlave != bond.ActiveSlave {
+		bondState = check.WorstState(bondState, changedState)
+		output += fmt.Sprintf(", active slave changed from %s since the previous execution", displayActiveSlave(*previousActiveSlave))
+	}
+
+	for _, slave := range bond.Slaves {
+		slaveResult := result.NewPartialResult()
+		slaveResult.SetDefaultState(check.OK)
+
+		slaveState := check.OK
+
+		slaveOutput := fmt.Sprintf("%s: MII status %s", slave.Name, slave.MIIStatus)
+
+		if slave.MIIStatus != bonding.MIIStatusUp {
+			slaveState = check.Warning
+		} else if slave.Speed != "" {
+			slaveOutput += ", " + slave.Speed
+
+			if slave.Duplex != "" {
+				slaveOutput += " " + slave.Duplex + " duplex"
+			}
+		}
+
+		slaveOutput += fmt.Sprintf(", %d link failures", slave.LinkFailureCount)
+
+		if delta, ok := linkFailures[slave.Name]; ok {
+			slaveOutput += fmt.Sprintf(" (%d since the previous execution)", delta)
+
+			failureState := config.LinkFailures.Evaluate(float64(delta), nil)
+			if failureState != check.OK {
+				slaveOutput += " violates threshold"
+			}
+
+			slaveState = check.WorstState(slaveState, failureState)
+		}
+
+		pdFailures := check.Perfdata{
+			Label: bond.Name + "_" + slave.Name + "_link_failures",
+			Value: slave.LinkFailureCount,
+			Uom:   "c",
+			Min:   0,
+		}
+
+		slaveResult.AddPerfdata(&pdFailures)
+		slaveResult.SetState(slaveState)
+		slaveResult.SetOutput(slaveOutput)
+
+		bondResult.AddSubcheck(slaveResult)
+
+		bondState = check.WorstState(bondState, slaveState)
+	}
+
+	bondResult.SetState(bondState)
+	bondResult.SetOutput(output)
+
+	return bondResult
+}
+
+func displayActiveSlave(name string) string {
+	if name == "" {
+		return "none"
+	}
+
+	return name
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/NETWAYS/check_system_basics/internal/bonding"
+
+	"github.com/NETWAYS/go-check"
+)
+
+var (
+	testBond = bonding.Bond{
+		Name:        "bond0",
+		Mode:        "active-backup",
+		ActiveSlave: "eno1",
+		MIIStatus:   "up",
+		Slaves: []bonding.Slave{
+			{Name: "eno1", MIIStatus: "up", Speed: "10000 Mbps", Duplex: "full"},
+			{Name: "eno2", MIIStatus: "up", Speed: "10000 Mbps", Duplex: "full", LinkFailureCount: 2},
+		},
+	}
+)
+
+func TestBondHealthy(t *testing.T) {
+	config := bonding.CheckConfig{}
+
+	res := computeBond(&testBond, nil, nil, check.Warning, &config)
+
+	if check.OK != res.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.OK, res.GetStatus())
+	}
+
+	expected := "[OK] bond0 (active-backup), active slave: eno1, 2/2 slaves up"
+	if res.String() != expected {
+		t.Fatalf("expected %v, got %v", expected, res.String())
+	}
+}
+
+func TestBondSlavesUp(t *testing.T) {
+	bond := testBond
+	bond.Slaves = []bonding.Slave{testBond.Slaves[0], {Name: "eno2", MIIStatus: "down"}}
+
+	config := bonding.CheckConfig{}
+
+	res := computeBond(&bond, nil, nil, check.Warning, &config)
+
+	if check.Warning != res.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.Warning, res.GetStatus())
+	}
+
+	_ = config.SlavesUp.Crit.Set("2:")
+
+	res = computeBond(&bond, nil, nil, check.Warning, &config)
+
+	if check.Critical != res.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.Critical, res.GetStatus())
+	}
+}
+
+func TestBondMinLinks(t *testing.T) {
+	bond := testBond
+	bond.MinLinks = 2
+	bond.Slaves = []bonding.Slave{testBond.Slaves[0], {Name: "eno2", MIIStatus: "down"}}
+
+	config := bonding.CheckConfig{}
+
+	res := computeBond(&bond, nil, nil, check.Warning, &config)
+
+	if check.Critical != res.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.Critical, res.GetStatus())
+	}
+
+	// Explicit thresholds replace min_links
+	_ = config.SlavesUp.Warn.Set("2:")
+
+	res = computeBond(&bond, nil, nil, check.Warning, &config)
+
+	if check.Warning != res.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.Warning, res.GetStatus())
+	}
+}
+
+func TestBondActiveSlaveChanged(t *testing.T) {
+	config := bonding.CheckConfig{}
+
+	previous := "eno1"
+
+	res := computeBond(&testBond, &previous, nil, check.Critical, &config)
+
+	if check.OK != res.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.OK, res.GetStatus())
+	}
+
+	previous = "eno2"
+
+	res = computeBond(&testBond, &previous, nil, check.Critical, &config)
+
+	if check.Critical != res.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.Critical, res.GetStatus())
+	}
+}
+
+func TestBondLinkFailures(t *testing.T) {
+	config := bonding.CheckConfig{}
+	_ = config.LinkFailures.Warn.Set("0")
+
+	res := computeBond(&testBond, nil, map[string]uint64{"eno1": 0, "eno2": 1}, check.Warning, &config)
+
+	if check.Warning != res.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.Warning, res.GetStatus())
+	}
+}
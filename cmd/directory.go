@@ -0,0 +1,243 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/NETWAYS/check_system_basics/internal/common/thresholds"
+	"github.com/NETWAYS/check_system_basics/internal/directory"
+	"github.com/NETWAYS/go-check"
+	"github.com/NETWAYS/go-check/convert"
+	"github.com/NETWAYS/go-check/result"
+	"github.com/spf13/cobra"
+)
+
+var DirectoryConfig directory.CheckConfig
+
+var directoryCmd = &cobra.Command{
+	Use:   "directory",
+	Short: "Submodule to check the size, the number and the age of the files in directories",
+	Long: `Submodule to check the size, the number and the age of the files in directories,
+e.g. a spool directory which is growing or a backup directory without a recent backup.
+Only regular files are counted, symbolic links below the directory are not followed, but the directory itself may be one.`,
+	Example: `./check_system_basics directory --path /var/spool/postfix/deferred --critical-files 10000 --path /backup --age-unit hours --critical-newest-age 26
+[CRITICAL] - states: critical=1 ok=1
+\_ [OK] /var/spool/postfix/deferred: 12 files, 96KiB, oldest 5.2 hours, newest 0.3 hours
+\_ [CRITICAL] /backup: 30 files, 1.2TiB, oldest 720.1 hours, newest 49.5 hours (violates threshold)
+|/var/spool/postfix/deferred_size=98304B;;;0 /var/spool/postfix/deferred_files=12;;10000;0 ...`,
+	Run: runCheck(directoryCheck),
+}
+
+func init() {
+	rootCmd.AddCommand(directoryCmd)
+
+	fs := directoryCmd.Flags()
+
+	fs.StringSliceVar(&DirectoryConfig.Paths, "path", nil,
+		"The directory to check (may be repeated)")
+	fs.IntVar(&DirectoryConfig.MaxDepth, "max-depth", -1,
+		"The number of levels below a path which are walked, 1 means only the files directly in the path. Unlimited if negative")
+	fs.StringSliceVar(&DirectoryConfig.Filters.Include, "include", nil,
+		"Explicitly count only files whose names match this glob pattern (may be repeated). E.g. '*.log'")
+	fs.StringSliceVar(&DirectoryConfig.Filters.Exclude, "exclude", nil,
+		"Ignore files and directories whose names match this glob pattern (may be repeated). E.g. '*.tmp', '.cache'")
+	fs.DurationVar(&DirectoryConfig.WalkTimeout, "walk-timeout", directory.DefaultWalkTimeout,
+		"The time the walk of a single path may take, the result is UNKNOWN if it takes longer. All walks together are limited to half of the timeout")
+	fs.StringVar(&DirectoryConfig.AgeUnit, "age-unit", directory.UnitHours,
+		"The unit of the age thresholds, one of 'seconds', 'minutes', 'hours' or 'days'")
+
+	directoryThresholds := []thresholds.ThresholdOption{
+		{
+			Th:          &DirectoryConfig.Size.Warn,
+			FlagString:  "warning-size",
+			Description: "Warning threshold for the size of all files in bytes",
+		},
+		{
+			Th:          &DirectoryConfig.Size.Crit,
+			FlagString:  "critical-size",
+			Description: "Critical threshold for the size of all files in bytes",
+		},
+		{
+			Th:          &DirectoryConfig.FileCount.Warn,
+			FlagString:  "warning-files",
+			Description: "Warning threshold for the number of files",
+		},
+		{
+			Th:          &DirectoryConfig.FileCount.Crit,
+			FlagString:  "critical-files",
+			Description: "Critical threshold for the number of files",
+		},
+		{
+			Th:          &DirectoryConfig.OldestAge.Warn,
+			FlagString:  "warning-oldest-age",
+			Description: "Warning threshold for the age of the oldest file (in the --age-unit)",
+		},
+		{
+			Th:          &DirectoryConfig.OldestAge.Crit,
+			FlagString:  "critical-oldest-age",
+			Description: "Critical threshold for the age of the oldest file (in the --age-unit)",
+		},
+		{
+			Th:          &DirectoryConfig.NewestAge.Warn,
+			FlagString:  "warning-newest-age",
+			Description: "Warning threshold for the age of the newest file (in the --age-unit). A directory without files violates any upper bound",
+		},
+		{
+			Th:          &DirectoryConfig.NewestAge.Crit,
+			FlagString:  "critical-newest-age",
+			Description: "Critical threshold for the age of the newest file (in the --age-unit). A directory without files violates any upper bound",
+		},
+	}
+
+	thresholds.AddFlags(fs, &directoryThresholds)
+
+	fs.SortFlags = false
+
+	registerCheck(directoryCmd, directoryCheck)
+}
+
+func directoryCheck(_ *cobra.Command) ([]*result.PartialResult, error) {
+	if len(DirectoryConfig.Paths) == 0 {
+		return nil, errors.New("at least one --path is needed")
+	}
+
+	unit, err := directory.UnitDuration(DirectoryConfig.AgeUnit)
+	if err != nil {
+		return nil, err
+	}
+
+	err = directory.ValidateFilter(&DirectoryConfig.Filters)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*result.PartialResult, 0, len(DirectoryConfig.Paths))
+
+	// All walks together may take half of the plugin timeout, the other half is left for the rest of the check
+	walks, cancelWalks := context.WithTimeout(context.Background(), time.Duration(Timeout)*time.Second/2)
+	defer cancelWalks()
+
+	for _, path := range DirectoryConfig.Paths {
+		ctx, cancel := context.WithTimeout(walks, DirectoryConfig.WalkTimeout)
+
+		stats, err := directory.Walk(ctx, path, DirectoryConfig.MaxDepth, &DirectoryConfig.Filters)
+
+		cancel()
+
+		if err != nil {
+			errResult := result.NewPartialResult()
+			errResult.SetState(check.Unknown)
+			errResult.SetOutput(fmt.Sprintf("%s: %s", path, err))
+
+			results = append(results, errResult)
+
+			continue
+		}
+
+		results = append(results, computeDirectory(&stats, time.Now(), unit, &DirectoryConfig))
+	}
+
+	return results, nil
+}
+
+// computeDirectory evaluates the statistics of a directory at the time now, unit is the duration of the configured age unit
+func computeDirectory(stats *directory.Stats, now time.Time, unit time.Duration, config *directory.CheckConfig) *result.PartialResult {
+	dirResult := result.NewPartialResult()
+	dirResult.SetDefaultState(check.OK)
+
+	pdSize := check.Perfdata{
+		Label: stats.Path + "_size",
+		Value: stats.Size,
+		Uom:   "B",
+		Min:   0,
+	}
+
+	pdFiles := check.Perfdata{
+		Label: stats.Path + "_files",
+		Value: stats.Files,
+		Min:   0,
+	}
+
+	sizeState := config.Size.Evaluate(float64(stats.Size), &pdSize)
+	filesState := config.FileCount.Evaluate(float64(stats.Files), &pdFiles)
+
+	output := fmt.Sprintf("%s: %d files%s, %s%s", stats.Path,
+		stats.Files, violation(filesState), convert.BytesIEC(stats.Size), violation(sizeState))
+
+	dirResult.AddPerfdata(&pdFiles)
+	dirResult.AddPerfdata(&pdSize)
+
+	states := []check.Status{sizeState, filesState}
+
+	if stats.Files == 0 {
+		// Without files there is no recent file
+		newestState := config.NewestAge.Evaluate(math.Inf(1), nil)
+
+		output += ", no files" + violation(newestState)
+
+		states = append(states, newestState)
+	} else {
+		for _, age := range []struct {
+			name       string
+			modTime    time.Time
+			thresholds *thresholds.Thresholds
+		}{
+			{"oldest", stats.Oldest, &config.OldestAge},
+			{"newest", stats.Newest, &config.NewestAge},
+		} {
+			seconds := now.Sub(age.modTime).Seconds()
+
+			pdAge := check.Perfdata{
+				Label: stats.Path + "_" + age.name + "_age",
+				Value: seconds,
+				Uom:   "s",
+			}
+
+			// The thresholds are given in the unit, the perfdata is in seconds
+			ageState := age.thresholds.Evaluate(seconds/unit.Seconds(), nil)
+
+			if age.thresholds.Warn.IsSet {
+				pdAge.Warn = scaleThreshold(age.thresholds.Warn.Th, unit.Seconds())
+			}
+
+			if age.thresholds.Crit.IsSet {
+				pdAge.Crit = scaleThreshold(age.thresholds.Crit.Th, unit.Seconds())
+			}
+
+			output += fmt.Sprintf(", %s %.1f %s%s", age.name, seconds/unit.Seconds(), config.AgeUnit, violation(ageState))
+
+			dirResult.AddPerfdata(&pdAge)
+
+			states = append(states, ageState)
+		}
+	}
+
+	if stats.Unreadable > 0 {
+		output += fmt.Sprintf(", %d entries could not be read", stats.Unreadable)
+	}
+
+	if !stats.Complete {
+		// A threshold violated by the incomplete numbers is still reported
+		output += ", walk aborted by the timeout, the numbers are incomplete"
+		states = append(states, check.Unknown)
+	}
+
+	state := check.WorstState(states...)
+
+	dirResult.SetState(state)
+	dirResult.SetOutput(output)
+
+	return dirResult
+}
+
+// violation marks a value which violates a threshold
+func violation(state check.Status) string {
+	if state == check.OK {
+		return ""
+	}
+
+	return " (violates threshold)"
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NETWAYS/check_system_basics/internal/directory"
+	"github.com/NETWAYS/go-check"
+)
+
+func TestComputeDirectory(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	config := directory.CheckConfig{AgeUnit: directory.UnitHours, WalkTimeout: 10 * time.Second}
+
+	err := config.FileCount.Crit.Set("100")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	err = config.NewestAge.Warn.Set("24")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	stats := directory.Stats{
+		Path:     "/backup",
+		Files:    3,
+		Size:     3 * 1024 * 1024,
+		Oldest:   now.Add(-72 * time.Hour),
+		Newest:   now.Add(-30 * time.Hour),
+		Complete: true,
+	}
+
+	result := computeDirectory(&stats, now, time.Hour, &config)
+
+	expected := "[WARNING] /backup: 3 files, 3MiB, oldest 72.0 hours, newest 30.0 hours (violates threshold)"
+	if result.String() != expected {
+		t.Fatalf("expected %v, got %v", expected, result.String())
+	}
+
+	stats.Newest = now.Add(-time.Hour)
+	stats.Files = 150
+
+	result = computeDirectory(&stats, now, time.Hour, &config)
+	if result.GetStatus() != check.Critical {
+		t.Fatalf("expected %v, got %v", check.Critical, result.GetStatus())
+	}
+}
+
+func TestComputeDirectoryEmpty(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	config := directory.CheckConfig{AgeUnit: directory.UnitHours, WalkTimeout: 10 * time.Second}
+
+	stats := directory.Stats{Path: "/backup", Complete: true}
+
+	result := computeDirectory(&stats, now, time.Hour, &config)
+	if result.String() != "[OK] /backup: 0 files, 0B, no files" {
+		t.Fatalf("expected %v, got %v", "[OK] /backup: 0 files, 0B, no files", result.String())
+	}
+
+	// A directory without files has no recent file
+	err := config.NewestAge.Crit.Set("26")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	result = computeDirectory(&stats, now, time.Hour, &config)
+	if result.GetStatus() != check.Critical {
+		t.Fatalf("expected %v, got %v", check.Critical, result.GetStatus())
+	}
+
+	// An incomplete walk does not hide a violated threshold
+	stats.Complete = false
+
+	result = computeDirectory(&stats, now, time.Hour, &config)
+	if result.GetStatus() != check.Critical {
+		t.Fatalf("expected %v, got %v", check.Critical, result.GetStatus())
+	}
+
+	config.NewestAge.Crit.IsSet = false
+
+	result = computeDirectory(&stats, now, time.Hour, &config)
+	if result.GetStatus() != check.Unknown {
+		t.Fatalf("expected %v, got %v", check.Unknown, result.GetStatus())
+	}
+
+	expected := "[UNKNOWN] /backup: 0 files, 0B, no files, walk aborted by the timeout, the numbers are incomplete"
+	if result.String() != expected {
+		t.Fatalf("expected %v, got %v", expected, result.String())
+	}
+}
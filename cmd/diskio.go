@@ -0,0 +1,298 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NETWAYS/check_system_basics/internal/common/state"
+	"github.com/NETWAYS/check_system_basics/internal/common/thresholds"
+	"github.com/NETWAYS/check_system_basics/internal/diskio"
+	"github.com/NETWAYS/go-check"
+	"github.com/NETWAYS/go-check/convert"
+	"github.com/NETWAYS/go-check/result"
+	"github.com/spf13/cobra"
+)
+
+var DiskioConfig diskio.CheckConfig
+
+var ExcludeDeviceNameDefaults = []string{
+	"^loop[0-9]+$",
+	"^ram[0-9]+$",
+	"^zram[0-9]+$",
+	"^sr[0-9]+$",
+	"^fd[0-9]+$",
+}
+
+var diskioCmd = &cobra.Command{
+	Use:   "diskio",
+	Short: "Submodule to check the I/O statistics of block devices",
+	Long: `Submodule to check the I/O statistics of block devices from /proc/diskstats.
+The IOPS, the throughput, the average time of an operation (await), the average number of operations
+in flight (queue depth) and the utilization are computed since the previous execution or,
+with --sample-interval, over an interval within the execution.`,
+	Example: `./check_system_basics diskio --warning-await 20 --critical-utilization 90
+[WARNING] - states: warning=1 ok=1
+\_ [WARNING] sda: 30.00 IOPS (20.00 read, 10.00 write), 1024KiB/s read, 512KiB/s written, await 25.00ms (read 2.00ms, write 71.00ms), queue depth 1.50, 50.00% utilized violates threshold
+\_ [OK] nvme0n1: 120.00 IOPS (100.00 read, 20.00 write), 4MiB/s read, 1MiB/s written, await 0.10ms (read 0.08ms, write 0.20ms), queue depth 0.02, 3.10% utilized
+|sda_iops=30;;;0 sda_read_iops=20;;;0 sda_write_iops=10;;;0 sda_read_bytes=1048576B;;;0 ...`,
+	Run: runCheck(diskioCheck),
+}
+
+func init() {
+	rootCmd.AddCommand(diskioCmd)
+
+	fs := diskioCmd.Flags()
+
+	fs.StringSliceVar(&DiskioConfig.Filters.IncludeDeviceNames, "include-device-name", nil,
+		"Explicitly include only devices whose names match this regexp regex (may be repeated). E.g. '^sd', '^nvme'")
+	fs.StringSliceVar(&DiskioConfig.Filters.ExcludeDeviceNames, "exclude-device-name", ExcludeDeviceNameDefaults,
+		"Ignore all devices where the device name matches this regexp regex (may be repeated). E.g. '^dm-'")
+	fs.BoolVar(&DiskioConfig.IncludePartitions, "include-partitions", false,
+		"Check partitions as well, by default only whole devices are checked")
+	fs.DurationVar(&DiskioConfig.SampleInterval, "sample-interval", 0,
+		"Compute the rates over this interval within the execution (e.g. '5s') instead of since the previous execution")
+
+	diskioThresholds := []thresholds.ThresholdOption{
+		{
+			Th:          &DiskioConfig.IOPS.Warn,
+			FlagString:  "warning-iops",
+			Description: "Warning threshold for the read and write operations per second of a device",
+		},
+		{
+			Th:          &DiskioConfig.IOPS.Crit,
+			FlagString:  "critical-iops",
+			Description: "Critical threshold for the read and write operations per second of a device",
+		},
+		{
+			Th:          &DiskioConfig.Throughput.Warn,
+			FlagString:  "warning-throughput",
+			Description: "Warning threshold for the read and written bytes per second of a device",
+		},
+		{
+			Th:          &DiskioConfig.Throughput.Crit,
+			FlagString:  "critical-throughput",
+			Description: "Critical threshold for the read and written bytes per second of a device",
+		},
+		{
+			Th:          &DiskioConfig.Await.Warn,
+			FlagString:  "warning-await",
+			Description: "Warning threshold for the average time of a read or write operation in milliseconds (including the time in the queue)",
+		},
+		{
+			Th:          &DiskioConfig.Await.Crit,
+			FlagString:  "critical-await",
+			Description: "Critical threshold for the average time of a read or write operation in milliseconds (including the time in the queue)",
+		},
+		{
+			Th:          &DiskioConfig.QueueDepth.Warn,
+			FlagString:  "warning-queue-depth",
+			Description: "Warning threshold for the average number of operations in flight",
+		},
+		{
+			Th:          &DiskioConfig.QueueDepth.Crit,
+			FlagString:  "critical-queue-depth",
+			Description: "Critical threshold for the average number of operations in flight",
+		},
+		{
+			Th:          &DiskioConfig.Utilization.Warn,
+			FlagString:  "warning-utilization",
+			Description: "Warning threshold for the percentage of the time the device was busy",
+		},
+		{
+			Th:          &DiskioConfig.Utilization.Crit,
+			FlagString:  "critical-utilization",
+			Description: "Critical threshold for the percentage of the time the device was busy",
+		},
+	}
+
+	thresholds.AddFlags(fs, &diskioThresholds)
+
+	fs.SortFlags = false
+
+	registerCheck(diskioCmd, diskioCheck)
+}
+
+func diskioCheck(cmd *cobra.Command) ([]*result.PartialResult, error) {
+	err := validateDiskioOptions(&DiskioConfig, time.Duration(Timeout)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	devices, err := diskio.GetAllDevices()
+	if err != nil {
+		return nil, err
+	}
+
+	devices, err = diskio.FilterDevices(devices, &DiskioConfig.Filters, DiskioConfig.IncludePartitions)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(devices) == 0 {
+		noDevices := result.NewPartialResult()
+		noDevices.SetState(check.Unknown)
+		noDevices.SetOutput("No block devices found")
+
+		return []*result.PartialResult{noDevices}, nil
+	}
+
+	results := make([]*result.PartialResult, 0, len(devices)+1)
+
+	if DiskioConfig.SampleInterval > 0 {
+		rates, err := diskio.SampleRates(devices, DiskioConfig.SampleInterval)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range devices {
+			deviceRates, ok := rates[devices[i].Name]
+			if !ok {
+				results = append(results, noDiskioRates(devices[i].Name, "device disappeared or was reset while sampling"))
+				continue
+			}
+
+			results = append(results, computeDiskio(devices[i].Name, &deviceRates, &DiskioConfig))
+		}
+
+		return results, nil
+	}
+
+	store, err := openStateStore(cmd, "diskio")
+	if err != nil {
+		return nil, err
+	}
+
+	if store.Previous == nil {
+		noRates := result.NewPartialResult()
+		noRates.SetState(check.OK)
+		noRates.SetOutput("No data from a previous execution, rates will be available with the next execution")
+		results = append(results, noRates)
+	}
+
+	for i := range devices {
+		rates, err := diskio.GetDeviceRates(store, &devices[i])
+
+		switch {
+		case err == nil:
+			results = append(results, computeDiskio(devices[i].Name, &rates, &DiskioConfig))
+		case store.Previous != nil:
+			// Without any previous data this was already reported once for all devices
+			results = append(results, noDiskioRates(devices[i].Name, state.Explain(err)))
+		}
+	}
+
+	err = store.Close()
+	if err != nil {
+		results = append(results, stateNotSaved(err))
+	}
+
+	return results, nil
+}
+
+// validateDiskioOptions checks the sample interval, which is zero to compute the rates since the previous
+// execution and must end before the timeout otherwise
+func validateDiskioOptions(config *diskio.CheckConfig, timeout time.Duration) error {
+	if config.SampleInterval < 0 || config.SampleInterval >= timeout {
+		return errors.New("--sample-interval must be zero or positive and less than the timeout")
+	}
+
+	return nil
+}
+
+func noDiskioRates(name, reason string) *result.PartialResult {
+	noRates := result.NewPartialResult()
+	noRates.SetState(check.OK)
+	noRates.SetOutput(name + ": rates not available: " + reason)
+
+	return noRates
+}
+
+// computeDiskio evaluates the thresholds on the rates of a device
+func computeDiskio(name string, rates *diskio.Rates, config *diskio.CheckConfig) *result.PartialResult {
+	returnResult := result.NewPartialResult()
+	returnResult.SetDefaultState(check.OK)
+
+	pdIOPS := check.Perfdata{
+		Label: name + "_iops",
+		Value: rates.IOPS(),
+		Min:   0,
+	}
+
+	pdThroughput := check.Perfdata{
+		Label: name + "_throughput",
+		Value: rates.Throughput(),
+		Uom:   "B",
+		Min:   0,
+	}
+
+	pdAwait := check.Perfdata{
+		Label: name + "_await",
+		Value: rates.Await / 1000,
+		Uom:   "s",
+		Min:   0,
+	}
+
+	pdQueueDepth := check.Perfdata{
+		Label: name + "_queue_depth",
+		Value: rates.QueueDepth,
+		Min:   0,
+	}
+
+	pdUtilization := check.Perfdata{
+		Label: name + "_utilization",
+		Value: rates.Utilization,
+		Uom:   "%",
+		Min:   0,
+		Max:   100,
+	}
+
+	awaitState := config.Await.Evaluate(rates.Await, nil)
+
+	// The await thresholds are given in milliseconds, the perfdata is in seconds
+	if config.Await.Warn.IsSet {
+		pdAwait.Warn = scaleThreshold(config.Await.Warn.Th, 0.001)
+	}
+
+	if config.Await.Crit.IsSet {
+		pdAwait.Crit = scaleThreshold(config.Await.Crit.Th, 0.001)
+	}
+
+	states := []check.Status{
+		config.IOPS.Evaluate(rates.IOPS(), &pdIOPS),
+		config.Throughput.Evaluate(rates.Throughput(), &pdThroughput),
+		awaitState,
+		config.QueueDepth.Evaluate(rates.QueueDepth, &pdQueueDepth),
+		config.Utilization.Evaluate(rates.Utilization, &pdUtilization),
+	}
+
+	output := strings.Builder{}
+	output.WriteString(fmt.Sprintf("%s: %.2f IOPS (%.2f read, %.2f write), %s/s read, %s/s written",
+		name, rates.IOPS(), rates.ReadsPerSecond, rates.WritesPerSecond,
+		convert.BytesIEC(uint64(rates.ReadBytes)), convert.BytesIEC(uint64(rates.WrittenBytes))))
+	output.WriteString(fmt.Sprintf(", await %.2fms (read %.2fms, write %.2fms), queue depth %.2f, %.2f%% utilized",
+		rates.Await, rates.ReadAwait, rates.WriteAwait, rates.QueueDepth, rates.Utilization))
+
+	returnResult.SetState(check.WorstState(states...))
+
+	if returnResult.GetStatus() != check.OK {
+		output.WriteString(" violates threshold")
+	}
+
+	returnResult.SetOutput(output.String())
+
+	returnResult.AddPerfdata(&pdIOPS)
+	returnResult.AddPerfdata(&check.Perfdata{Label: name + "_read_iops", Value: rates.ReadsPerSecond, Min: 0})
+	returnResult.AddPerfdata(&check.Perfdata{Label: name + "_write_iops", Value: rates.WritesPerSecond, Min: 0})
+	returnResult.AddPerfdata(&pdThroughput)
+	returnResult.AddPerfdata(&check.Perfdata{Label: name + "_read_bytes", Value: rates.ReadBytes, Uom: "B", Min: 0})
+	returnResult.AddPerfdata(&check.Perfdata{Label: name + "_written_bytes", Value: rates.WrittenBytes, Uom: "B", Min: 0})
+	returnResult.AddPerfdata(&pdAwait)
+	returnResult.AddPerfdata(&check.Perfdata{Label: name + "_read_await", Value: rates.ReadAwait / 1000, Uom: "s", Min: 0})
+	returnResult.AddPerfdata(&check.Perfdata{Label: name + "_write_await", Value: rates.WriteAwait / 1000, Uom: "s", Min: 0})
+	returnResult.AddPerfdata(&pdQueueDepth)
+	returnResult.AddPerfdata(&pdUtilization)
+
+	return returnResult
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NETWAYS/check_system_basics/internal/diskio"
+	"github.com/NETWAYS/go-check"
+)
+
+func TestComputeDiskio(t *testing.T) {
+	rates := diskio.Rates{
+		ReadsPerSecond:  20,
+		WritesPerSecond: 10,
+		ReadBytes:       1024 * 1024,
+		WrittenBytes:    512 * 1024,
+		ReadAwait:       2,
+		WriteAwait:      71,
+		Await:           25,
+		QueueDepth:      1.5,
+		Utilization:     50,
+	}
+
+	config := diskio.CheckConfig{}
+
+	result := computeDiskio("sda", &rates, &config)
+
+	expected := "[OK] sda: 30.00 IOPS (20.00 read, 10.00 write), 1024KiB/s read, 512KiB/s written, await 25.00ms (read 2.00ms, write 71.00ms), queue depth 1.50, 50.00% utilized"
+	if result.String() != expected {
+		t.Fatalf("expected %v, got %v", expected, result.String())
+	}
+
+	err := config.Await.Warn.Set("20")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	result = computeDiskio("sda", &rates, &config)
+	if result.GetStatus() != check.Warning {
+		t.Fatalf("expected %v, got %v", check.Warning, result.GetStatus())
+	}
+
+	err = config.Utilization.Crit.Set("40")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	result = computeDiskio("sda", &rates, &config)
+	if result.GetStatus() != check.Critical {
+		t.Fatalf("expected %v, got %v", check.Critical, result.GetStatus())
+	}
+}
+
+func TestValidateDiskioOptions(t *testing.T) {
+	for interval, valid := range map[time.Duration]bool{
+		-time.Second:      false,
+		0:                 true,
+		29 * time.Second:  true,
+		30 * time.Second:  false,
+		300 * time.Second: false,
+	} {
+		config := diskio.CheckConfig{SampleInterval: interval}
+
+		err := validateDiskioOptions(&config, 30*time.Second)
+		if (err == nil) != valid {
+			t.Fatalf("expected valid=%v for %s, got %v", valid, interval, err)
+		}
+	}
+}
@@ -6,8 +6,10 @@ import (
 	"fmt"
 	"slices"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/NETWAYS/check_system_basics/internal/common/state"
 	"github.com/NETWAYS/check_system_basics/internal/common/thresholds"
 	"github.com/NETWAYS/check_system_basics/internal/filesystem"
 	"github.com/NETWAYS/go-check"
@@ -69,101 +71,558 @@ var diskCmd = &cobra.Command{
 		\_ [OK] Percentage of used space: 48.05%
 		\_ [OK] Percentage of used inodes: 5.23%
 |/_inodes_free_percentage=82.688% /_space_free=33146855424B;@40;@20;0;62669000704 /_space_used=26305536000B;40;20;0;62669000704 /_inodes_free=3231100;;@200;0;3907584 /_inodes_used=676484;;@400;0;3907584 /_space_free_percentage=55.754%;60;30 /_space_used_percentage=44.246%;@20:40;@20 /_inodes_used_percentage=17.312%;99;98 /boot_inodes_free_percentage=99.718% /boot_space_free=173091840B;@40;@20;0;493201408 /boot_space_used=294524928B;40;20;0;493201408 /boot_inodes_free=124576;;@200;0;124928 /boot_inodes_used=352;;@400;0;124928 /boot_space_free_percentage=37.016%;60;30 /boot_space_used_percentage=62.984%;@20:40;@20 /boot_inodes_used_percentage=0.282%;99;98 /var_inodes_free_percentage=92.832% /var_space_free=141826428928B;@40;@20;0;250843787264 /var_space_used=96200613888B;40;20;0;250843787264 /var_inodes_free=14510026;;@200;0;15630336 /var_inodes_used=1120310;;@400;0;15630336 /var_space_free_percentage=59.584%;60;30 /var_space_used_percentage=40.416%;@20:40;@20 /var_inodes_used_percentage=7.168%;99;98 /home_inodes_free_percentage=94.768% /home_space_free=247921197056B;@40;@20;0;502813065216 /home_space_used=229275156480B;40;20;0;502813065216 /home_inodes_free=29617311;;@200;0;31252480 /home_inodes_used=1635169;;@400;0;31252480 /home_space_free_percentage=51.954%;60;30 /home_space_used_percentage=48.046%;@20:40;@20 /home_inodes_used_percentage=5.232%;99;98`,
-	Run: func(_ *cobra.Command, _ []string) {
-		overall := result.Overall{}
+	Run: runCheck(filesystemCheck),
+}
 
-		err := validateOptions(&FsConfig)
-		if err != nil {
-			check.ExitError(err)
+func filesystemCheck(cmd *cobra.Command) ([]*result.PartialResult, error) {
+	results := make([]*result.PartialResult, 0)
+
+	err := validateOptions(&FsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// Detect file systems
+	filesystems, err := disk.Partitions(true)
+	if err != nil {
+		return nil, err
+	}
+
+	if debug {
+		fmt.Printf("==== Detected filesystems: ====\n %v\n", filesystems)
+	}
+
+	filesystemList := make([]filesystem.FilesystemType, len(filesystems))
+
+	for i := range filesystems {
+		filesystemList[i].PartStats = filesystems[i]
+	}
+
+	if debug {
+		fmt.Printf("==== Filesystem List: ====\n %v\n", filesystemList)
+	}
+
+	// The filter might return the same list, which is modified later on
+	mountedList := slices.Clone(filesystemList)
+
+	// Filter out unwanted
+	filesystemList, err = filesystem.FilterFileSystem(filesystemList, &FsConfig.Filters)
+	if err != nil {
+		return nil, err
+	}
+
+	if debug {
+		fmt.Printf("==== Filtered Filesystem List: ====\n %v\n", filesystemList)
+	}
+
+	if FsConfig.CriticalTotalCountOfFs.IsSet || FsConfig.WarningTotalCountOfFs.IsSet {
+		countResult := result.NewPartialResult()
+		countResult.SetDefaultState(check.OK)
+
+		tmpOutput := ""
+		if len(filesystemList) == 1 {
+			tmpOutput = "Found one matching filesystem"
+		} else {
+			tmpOutput = "Found " + strconv.Itoa(len(filesystemList)) + " matching filesystems"
 		}
 
-		// Detect file systems
-		filesystems, err := disk.Partitions(true)
-		if err != nil {
-			check.ExitError(err)
+		if FsConfig.CriticalTotalCountOfFs.IsSet && FsConfig.CriticalTotalCountOfFs.Th.DoesViolate(float64(len(filesystemList))) {
+			countResult.SetState(check.Critical)
+
+			tmpOutput += ". This violates the threshold of " + FsConfig.CriticalTotalCountOfFs.String()
+		} else if FsConfig.WarningTotalCountOfFs.IsSet && FsConfig.WarningTotalCountOfFs.Th.DoesViolate(float64(len(filesystemList))) {
+			countResult.SetState(check.Warning)
+
+			tmpOutput += ". This violates the threshold of " + FsConfig.WarningTotalCountOfFs.String()
+		} else {
+			tmpOutput += ". This number resides within the given thresholds"
 		}
 
-		if debug {
-			fmt.Printf("==== Detected filesystems: ====\n %v\n", filesystems)
+		countResult.SetOutput(tmpOutput)
+
+		results = append(results, countResult)
+	}
+
+	var fstabEntries []filesystem.FstabEntry
+
+	if FsConfig.CheckFstab || FsConfig.CheckRoRemount {
+		fstabEntries, err = filesystem.ReadFstab(FsConfig.FstabPath)
+		if err != nil {
+			return nil, err
 		}
+	}
 
-		filesystemList := make([]filesystem.FilesystemType, len(filesystems))
+	if FsConfig.CheckFstab {
+		results = append(results, computeFstabResult(filesystem.CompareFstab(fstabEntries, mountedList, filesystemList)))
+	}
+
+	if !FsConfig.CriticalTotalCountOfFs.IsSet && !FsConfig.WarningTotalCountOfFs.IsSet && len(filesystemList) == 0 {
+		nullResult := result.NewPartialResult()
+		nullResult.SetState(check.OK)
+		nullResult.SetOutput("No filesystems remaining after applying filter expressions. Therefore all are OK")
+		results = append(results, nullResult)
+
+		return results, nil
+	}
+
+	// Retrieve stats, half of the plugin timeout is left for the rest of the check
+	internalTimeout := time.Duration(Timeout) * time.Second / 2
+	ctx := context.Background()
+
+	err = filesystem.GetDiskUsage(ctx, internalTimeout, filesystemList, &FsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		store    *state.Store
+		storeErr error
+	)
+
+	// Without the state only the forecast is not available, the usage is still evaluated
+	if FsConfig.Forecast.Enabled() {
+		store, storeErr = openStateStore(cmd, "filesystem")
+	}
+
+	// Compile the result
+	for index := range filesystemList {
+		fsConfig, rule := FsConfig.ForFilesystem(&filesystemList[index])
+
+		sc := computeFsCheckResult(&filesystemList[index], fsConfig)
+
+		if filesystemList[index].Error == nil {
+			sc.SetOutput(fsOutput(&filesystemList[index], rule))
+
+			if filesystemList[index].PartStats.Fstype == "btrfs" && FsConfig.Btrfs.Enabled() {
+				sc.AddSubcheck(checkBtrfs(&filesystemList[index], &FsConfig.Btrfs))
+			}
+
+			if FsConfig.RoRemountEnabled() {
+				roResult := checkRoRemount(&filesystemList[index], fstabEntries, &FsConfig)
+				if roResult != nil {
+					sc.AddSubcheck(roResult)
+				}
+			}
 
-		for i := range filesystems {
-			filesystemList[i].PartStats = filesystems[i]
+			if store != nil {
+				for _, forecast := range recordFsForecast(store, &filesystemList[index], &FsConfig.Forecast) {
+					sc.AddSubcheck(forecast)
+				}
+			}
+		} else if store != nil {
+			// The series of a hung or not queried filesystem continue with the next successful execution
+			keepFsForecast(store, &filesystemList[index], &FsConfig.Forecast)
 		}
 
-		if debug {
-			fmt.Printf("==== Filesystem List: ====\n %v\n", filesystemList)
+		if storeErr != nil {
+			noForecast := result.NewPartialResult()
+			noForecast.SetState(check.Unknown)
+			noForecast.SetOutput("Forecast state not available: " + storeErr.Error())
+			sc.AddSubcheck(noForecast)
 		}
 
-		// Filter out unwanted
-		filesystemList, err = filesystem.FilterFileSystem(filesystemList, &FsConfig.Filters)
+		results = append(results, sc)
+	}
+
+	if store != nil {
+		err = store.Close()
 		if err != nil {
-			check.ExitError(err)
+			results = append(results, stateNotSaved(err))
 		}
+	}
+
+	return results, nil
+}
+
+// recordFsForecast records the current usage of the filesystem in store and forecasts the time until
+// the space (and the inodes, if their number is fixed) are exhausted
+func recordFsForecast(store *state.Store, fs *filesystem.FilesystemType, config *filesystem.ForecastConfig) []*result.PartialResult {
+	// The unit is validated before
+	unitSeconds, _ := config.UnitSeconds()
+
+	prefix := fs.PartStats.Mountpoint + "_"
+
+	spaceSamples := store.AddSample(prefix+"space_used", float64(fs.UsageStats.Used), config.Window)
+
+	results := []*result.PartialResult{
+		computeFsForecast(fs, "space", float64(fsSpaceCapacity(fs)), spaceSamples, &config.SpaceTimeToFull, config, unitSeconds),
+	}
+
+	if slices.Contains(filesystem.GetFilesystemsWithFixedNumberOfInodes(), fs.PartStats.Fstype) {
+		inodeSamples := store.AddSample(prefix+"inodes_used", float64(fs.UsageStats.InodesUsed), config.Window)
+
+		results = append(results,
+			computeFsForecast(fs, "inodes", float64(fs.UsageStats.InodesTotal), inodeSamples, &config.InodesTimeToFull, config, unitSeconds))
+	}
+
+	return results
+}
+
+// keepFsForecast carries the forecast series of a filesystem, whose usage could not be read, over to the next execution
+func keepFsForecast(store *state.Store, fs *filesystem.FilesystemType, config *filesystem.ForecastConfig) {
+	prefix := fs.PartStats.Mountpoint + "_"
+
+	store.KeepSeries(prefix+"space_used", config.Window)
+	store.KeepSeries(prefix+"inodes_used", config.Window)
+}
+
+// fsSpaceCapacity returns the space usable by unprivileged users. The blocks reserved for root are
+// not part of it, the filesystem is full for the applications before they are used
+func fsSpaceCapacity(fs *filesystem.FilesystemType) uint64 {
+	return fs.UsageStats.Used + fs.UsageStats.Free
+}
+
+// computeFsForecast evaluates the estimated time until the space or the inodes (kind) of the filesystem are
+// exhausted. samples are the used bytes or inodes recorded within the forecast window, the last one is the current value
+func computeFsForecast(fs *filesystem.FilesystemType, kind string, total float64, samples []state.Sample,
+	timeToFull *thresholds.Thresholds, config *filesystem.ForecastConfig, unitSeconds float64) *result.PartialResult {
+	forecastResult := result.NewPartialResult()
+	forecastResult.SetDefaultState(check.OK)
+
+	title := strings.ToUpper(kind[:1]) + kind[1:] + " forecast"
+
+	rate, ok := filesystem.GrowthRate(samples)
+	if !ok {
+		forecastResult.SetOutput(fmt.Sprintf("%s: %d of %d samples within the last %s, the forecast will be available with more executions",
+			title, len(samples), filesystem.MinForecastSamples, config.Window))
+
+		return forecastResult
+	}
+
+	prefix := fs.PartStats.Mountpoint + "_" + kind
+
+	forecastResult.AddPerfdata(&check.Perfdata{
+		Label: prefix + "_growth_rate",
+		Value: rate,
+	})
+
+	output := fmt.Sprintf("%s: %s per day", title, formatFsGrowth(kind, rate))
+
+	seconds, growing := filesystem.SecondsToFull(samples[len(samples)-1].Value, total, rate)
+	if !growing {
+		forecastResult.SetOutput(output + ", not growing")
+
+		return forecastResult
+	}
+
+	pdTimeToFull := check.Perfdata{
+		Label: prefix + "_time_to_full",
+		Value: seconds,
+		Uom:   "s",
+		Min:   0,
+	}
+
+	// The thresholds are given in the configured unit, the perfdata is in seconds
+	forecastState := timeToFull.Evaluate(seconds/unitSeconds, nil)
+
+	if timeToFull.Warn.IsSet {
+		pdTimeToFull.Warn = scaleThreshold(timeToFull.Warn.Th, unitSeconds)
+	}
+
+	if timeToFull.Crit.IsSet {
+		pdTimeToFull.Crit = scaleThreshold(timeToFull.Crit.Th, unitSeconds)
+	}
+
+	output += fmt.Sprintf(", full in %.1f %s", seconds/unitSeconds, config.Unit)
+
+	if forecastState != check.OK {
+		output += " violates threshold"
+	}
+
+	forecastResult.AddPerfdata(&pdTimeToFull)
+	forecastResult.SetState(forecastState)
+	forecastResult.SetOutput(output)
+
+	return forecastResult
+}
+
+// formatFsGrowth describes the growth per day of the space or the inodes (kind) given a rate per second
+func formatFsGrowth(kind string, rate float64) string {
+	direction := "growing by"
+	if rate < 0 {
+		direction = "shrinking by"
+		rate = -rate
+	}
+
+	perDay := rate * (24 * time.Hour).Seconds()
+
+	if kind == "space" {
+		return direction + " " + convert.BytesIEC(uint64(perDay))
+	}
+
+	return fmt.Sprintf("%s %.0f inodes", direction, perDay)
+}
+
+// scaleThreshold returns a copy of th with its bounds multiplied by factor
+func scaleThreshold(th check.Threshold, factor float64) *check.Threshold {
+	return &check.Threshold{
+		Inside: th.Inside,
+		Lower:  th.Lower * factor,
+		Upper:  th.Upper * factor,
+	}
+}
+
+// checkBtrfs reads and evaluates the allocation of a btrfs filesystem, which is UNKNOWN if it can not be read
+func checkBtrfs(fs *filesystem.FilesystemType, config *filesystem.BtrfsConfig) *result.PartialResult {
+	info, err := filesystem.GetBtrfsInfo(fs)
+	if err != nil {
+		btrfsResult := result.NewPartialResult()
+		btrfsResult.SetState(check.Unknown)
+		btrfsResult.SetOutput(fmt.Sprintf("Could not read the btrfs allocation: %s", err))
+
+		return btrfsResult
+	}
+
+	return computeBtrfs(fs, info, config)
+}
+
+// computeBtrfs evaluates the data and metadata usage of a btrfs filesystem relative to the space they could grow to
+// (allocated plus unallocated space), as the statfs numbers hide an exhausted metadata space. The unallocated space
+// and the device error counters are reported as well
+func computeBtrfs(fs *filesystem.FilesystemType, info *filesystem.BtrfsInfo, config *filesystem.BtrfsConfig) *result.PartialResult {
+	btrfsResult := result.NewPartialResult()
+	btrfsResult.SetDefaultState(check.OK)
+	btrfsResult.SetOutput(fmt.Sprintf("Btrfs: %s of %s unallocated", convert.BytesIEC(info.Unallocated), convert.BytesIEC(info.Size)))
+
+	prefix := fs.PartStats.Mountpoint + "_btrfs_"
+
+	btrfsResult.AddPerfdata(&check.Perfdata{
+		Label: prefix + "unallocated",
+		Value: info.Unallocated,
+		Uom:   "B",
+		Min:   0,
+		Max:   info.Size,
+	})
+
+	for i := range info.Allocations {
+		allocation := &info.Allocations[i]
 
-		if debug {
-			fmt.Printf("==== Filtered Filesystem List: ====\n %v\n", filesystemList)
+		allocationResult := result.NewPartialResult()
+		allocationResult.SetDefaultState(check.OK)
+
+		usable := allocation.Usable(info.Unallocated)
+		usedPercent := allocation.UsedPercent(info.Unallocated)
+
+		pdUsed := check.Perfdata{
+			Label: prefix + allocation.Type + "_used",
+			Value: allocation.BytesUsed,
+			Uom:   "B",
+			Min:   0,
+			Max:   usable,
 		}
 
-		if FsConfig.CriticalTotalCountOfFs.IsSet || FsConfig.WarningTotalCountOfFs.IsSet {
-			countResult := result.NewPartialResult()
-			countResult.SetDefaultState(check.OK)
+		pdUsedPercentage := check.Perfdata{
+			Label: prefix + allocation.Type + "_used_percentage",
+			Value: usedPercent,
+			Uom:   "%",
+			Min:   0,
+			Max:   100,
+		}
 
-			tmpOutput := ""
-			if len(filesystemList) == 1 {
-				tmpOutput = "Found one matching filesystem"
-			} else {
-				tmpOutput = "Found " + strconv.Itoa(len(filesystemList)) + " matching filesystems"
-			}
+		output := fmt.Sprintf("%s%s: %s used, %s allocated, %s usable (%.2f%%)",
+			strings.ToUpper(allocation.Type[:1]), allocation.Type[1:], convert.BytesIEC(allocation.BytesUsed),
+			convert.BytesIEC(allocation.TotalBytes), convert.BytesIEC(usable), usedPercent)
 
-			if FsConfig.CriticalTotalCountOfFs.IsSet && FsConfig.CriticalTotalCountOfFs.Th.DoesViolate(float64(len(filesystemList))) {
-				countResult.SetState(check.Critical)
+		var th *thresholds.Thresholds
 
-				tmpOutput += ". This violates the threshold of " + FsConfig.CriticalTotalCountOfFs.String()
-			} else if FsConfig.WarningTotalCountOfFs.IsSet && FsConfig.WarningTotalCountOfFs.Th.DoesViolate(float64(len(filesystemList))) {
-				countResult.SetState(check.Warning)
+		switch allocation.Type {
+		case filesystem.BtrfsData:
+			th = &config.DataUsage
+		case filesystem.BtrfsMetadata:
+			th = &config.MetadataUsage
+		}
 
-				tmpOutput += ". This violates the threshold of " + FsConfig.WarningTotalCountOfFs.String()
-			} else {
-				tmpOutput += ". This number resides within the given thresholds"
+		if th != nil {
+			allocationState := th.Evaluate(usedPercent, &pdUsedPercentage)
+			if allocationState != check.OK {
+				output += " violates threshold"
 			}
 
-			countResult.SetOutput(tmpOutput)
+			allocationResult.SetState(allocationState)
+		}
+
+		allocationResult.AddPerfdata(&pdUsed)
+		allocationResult.AddPerfdata(&pdUsedPercentage)
+		allocationResult.SetOutput(output)
 
-			overall.AddSubcheck(countResult)
-		} else if len(filesystemList) == 0 {
-			nullResult := result.NewPartialResult()
-			nullResult.SetState(check.OK)
-			nullResult.SetOutput("No filesystems remaining after applying filter expressions. Therefore all are OK")
-			overall.AddSubcheck(nullResult)
-			check.Exit(overall.GetStatus(), overall.GetOutput())
+		btrfsResult.AddSubcheck(allocationResult)
+	}
+
+	for _, device := range info.Devices {
+		deviceResult := result.NewPartialResult()
+		deviceResult.SetDefaultState(check.OK)
+
+		errorNames := make([]string, 0, len(device.Errors))
+		for name := range device.Errors {
+			errorNames = append(errorNames, name)
 		}
 
-		// Retrieve stats
-		internalTimeout := time.Duration(Timeout/2) * time.Second
-		ctx := context.Background()
+		slices.Sort(errorNames)
 
-		err = filesystem.GetDiskUsage(ctx, internalTimeout, filesystemList, &FsConfig)
-		if err != nil {
-			check.ExitError(err)
+		counters := make([]string, 0, len(errorNames))
+		for _, name := range errorNames {
+			counters = append(counters, fmt.Sprintf("%s=%d", name, device.Errors[name]))
 		}
 
-		// Compile the result
-		for index := range filesystemList {
-			sc := computeFsCheckResult(&filesystemList[index], &FsConfig)
+		pdErrors := check.Perfdata{
+			Label: prefix + "device" + device.ID + "_errors",
+			Value: device.TotalErrors(),
+			Uom:   "c",
+			Min:   0,
+		}
 
-			if filesystemList[index].Error == nil {
-				sc.SetOutput(fmt.Sprintf("%s (%.2f%% used space, %.2f%% free inodes)", filesystemList[index].PartStats.Mountpoint, filesystemList[index].UsageStats.UsedPercent, 100-filesystemList[index].UsageStats.InodesUsedPercent))
-			}
+		output := fmt.Sprintf("Device %s: %d errors (%s)", device.ID, device.TotalErrors(), strings.Join(counters, ", "))
+
+		deviceState := config.DeviceErrors.Evaluate(float64(device.TotalErrors()), &pdErrors)
+		if deviceState != check.OK {
+			output += " violates threshold"
+		}
+
+		deviceResult.AddPerfdata(&pdErrors)
+		deviceResult.SetState(deviceState)
+		deviceResult.SetOutput(output)
+
+		btrfsResult.AddSubcheck(deviceResult)
+	}
+
+	return btrfsResult
+}
+
+// checkRoRemount determines whether the filesystem should be mounted read-write and reads its kernel error counters.
+// A failure only results in an UNKNOWN state for this filesystem. The result is nil if there is nothing to report
+func checkRoRemount(fs *filesystem.FilesystemType, fstabEntries []filesystem.FstabEntry, config *filesystem.CheckConfig) *result.PartialResult {
+	expectsRW, err := filesystem.ExpectsReadWrite(fs, fstabEntries, config.ExpectedRWMountPaths)
+	if err != nil {
+		return roRemountUnknown(err)
+	}
+
+	kernelErrors, err := filesystem.GetKernelErrors(fs)
+	if err != nil {
+		return roRemountUnknown(err)
+	}
+
+	return computeRoRemount(fs, expectsRW, kernelErrors)
+}
+
+func roRemountUnknown(err error) *result.PartialResult {
+	roResult := result.NewPartialResult()
+	roResult.SetState(check.Unknown)
+	roResult.SetOutput(fmt.Sprintf("Could not check for a read-only remount: %s", err))
+
+	return roResult
+}
+
+// computeRoRemount reports a filesystem which is mounted read-only, but configured read-write, as CRITICAL,
+// e.g. after ext4 or xfs remounted it due to I/O errors. The kernel error counters are added to the output
+// and the perfdata. The result is nil if the filesystem is not expected to be read-write and has no counters
+func computeRoRemount(fs *filesystem.FilesystemType, expectsRW bool, kernelErrors []filesystem.KernelError) *result.PartialResult {
+	if !expectsRW && len(kernelErrors) == 0 {
+		return nil
+	}
+
+	roResult := result.NewPartialResult()
+	roResult.SetDefaultState(check.OK)
+
+	var output string
+
+	switch {
+	case fs.IsReadOnly() && expectsRW:
+		roResult.SetState(check.Critical)
+
+		output = "Mounted read-only, but configured read-write"
+	case fs.IsReadOnly():
+		output = "Mounted read-only"
+	default:
+		output = "Mounted read-write"
+	}
+
+	details := make([]string, 0, len(kernelErrors))
+
+	for _, kernelError := range kernelErrors {
+		pd := check.Perfdata{
+			Label: fs.PartStats.Mountpoint + "_" + strings.ReplaceAll(kernelError.Name, "/", "_"),
+			Value: kernelError.Value,
+		}
 
-			overall.AddSubcheck(sc)
+		value := strconv.FormatInt(kernelError.Value, 10)
+
+		switch {
+		case kernelError.Name == "errors_count":
+			pd.Uom = "c"
+			pd.Min = 0
+		case strings.HasSuffix(kernelError.Name, "_time"):
+			value = "never"
+
+			if kernelError.Value > 0 {
+				value = time.Unix(kernelError.Value, 0).UTC().Format(time.RFC3339)
+			}
 		}
 
-		// Output and Exit
-		check.Exit(overall.GetStatus(), overall.GetOutput())
-	},
+		details = append(details, kernelError.Name+"="+value)
+
+		roResult.AddPerfdata(&pd)
+	}
+
+	if len(details) > 0 {
+		output += " (" + fs.PartStats.Fstype + " " + strings.Join(details, ", ") + ")"
+	}
+
+	roResult.SetOutput(output)
+
+	return roResult
+}
+
+// computeFstabResult reports the fstab entries which are not mounted (CRITICAL), the mounts which
+// differ from the fstab (WARNING) and the mounts without an fstab entry (WARNING)
+func computeFstabResult(comparison filesystem.FstabComparison) *result.PartialResult {
+	fstabResult := result.NewPartialResult()
+	fstabResult.SetDefaultState(check.OK)
+
+	for _, entry := range comparison.NotMounted {
+		entryResult := result.NewPartialResult()
+		entryResult.SetState(check.Critical)
+		entryResult.SetOutput(fmt.Sprintf("%s (%s) is not mounted", entry.Mountpoint, entry.Device))
+
+		fstabResult.AddSubcheck(entryResult)
+	}
+
+	for _, mismatch := range comparison.Mismatches {
+		mismatchResult := result.NewPartialResult()
+		mismatchResult.SetState(check.Warning)
+		mismatchResult.SetOutput(fmt.Sprintf("%s (%s) differs from the fstab: %s",
+			mismatch.Entry.Mountpoint, mismatch.Mount.PartStats.Device, strings.Join(mismatch.Differences, ", ")))
+
+		fstabResult.AddSubcheck(mismatchResult)
+	}
+
+	for _, mount := range comparison.Unexpected {
+		mountResult := result.NewPartialResult()
+		mountResult.SetState(check.Warning)
+		mountResult.SetOutput(fmt.Sprintf("%s (%s, %s) is mounted, but not in the fstab",
+			mount.PartStats.Mountpoint, mount.PartStats.Device, mount.PartStats.Fstype))
+
+		fstabResult.AddSubcheck(mountResult)
+	}
+
+	if len(comparison.NotMounted) == 0 && len(comparison.Mismatches) == 0 && len(comparison.Unexpected) == 0 {
+		fstabResult.SetOutput("fstab: all filesystems are mounted as configured")
+	} else {
+		fstabResult.SetOutput(fmt.Sprintf("fstab: %d not mounted, %d differing, %d unexpected",
+			len(comparison.NotMounted), len(comparison.Mismatches), len(comparison.Unexpected)))
+	}
+
+	return fstabResult
+}
+
+// fsOutput summarizes the usage of a filesystem and names the threshold rule applied to it
+func fsOutput(fs *filesystem.FilesystemType, rule string) string {
+	output := fmt.Sprintf("%s (%.2f%% used space, %.2f%% free inodes", fs.PartStats.Mountpoint, fs.UsageStats.UsedPercent, 100-fs.UsageStats.InodesUsedPercent)
+
+	if rule != "" {
+		output += ", rule: " + rule
+	}
+
+	return output + ")"
 }
 
 func computeFsCheckResultInodes(fs *filesystem.FilesystemType, config *filesystem.CheckConfig) *result.PartialResult {
@@ -511,6 +970,21 @@ func computeFsCheckResult(fs *filesystem.FilesystemType, config *filesystem.Chec
 	returnResult.SetOutput(fs.PartStats.Mountpoint)
 	returnResult.SetDefaultState(check.OK)
 
+	if fs.IsHung() {
+		returnResult.SetState(config.HungState)
+		returnResult.SetOutput(fmt.Sprintf("Filesystem mounted at %s (%s) is hung: %s", fs.PartStats.Mountpoint, fs.PartStats.Device, fs.Error))
+
+		return returnResult
+	}
+
+	// The time ran out while other filesystems were queried, which is most likely caused by hung filesystems as well
+	if errors.Is(fs.Error, filesystem.ErrNotQueried) {
+		returnResult.SetState(config.HungState)
+		returnResult.SetOutput(fmt.Sprintf("Filesystem mounted at %s (%s) was not queried: %s", fs.PartStats.Mountpoint, fs.PartStats.Device, fs.Error))
+
+		return returnResult
+	}
+
 	if fs.Error != nil {
 		returnResult.SetState(check.Unknown)
 		returnResult.SetOutput(fmt.Sprintf("Could not determine status of the filesystem  mounted at %s (%s) stats due to: %s", fs.PartStats.Mountpoint, fs.PartStats.Device, fs.Error))
@@ -633,6 +1107,56 @@ func init() {
 				},
 			},
 		},
+		{
+			Th:          &FsConfig.Forecast.SpaceTimeToFull.Warn,
+			FlagString:  "warningTimeToFullSpace",
+			Description: "Warning threshold for the estimated time until the filesystem space is full (in the --forecast-unit). E.g. '48:'",
+		},
+		{
+			Th:          &FsConfig.Forecast.SpaceTimeToFull.Crit,
+			FlagString:  "criticalTimeToFullSpace",
+			Description: "Critical threshold for the estimated time until the filesystem space is full (in the --forecast-unit). E.g. '12:'",
+		},
+		{
+			Th:          &FsConfig.Forecast.InodesTimeToFull.Warn,
+			FlagString:  "warningTimeToFullInodes",
+			Description: "Warning threshold for the estimated time until all inodes are used (in the --forecast-unit)",
+		},
+		{
+			Th:          &FsConfig.Forecast.InodesTimeToFull.Crit,
+			FlagString:  "criticalTimeToFullInodes",
+			Description: "Critical threshold for the estimated time until all inodes are used (in the --forecast-unit)",
+		},
+		{
+			Th:          &FsConfig.Btrfs.DataUsage.Warn,
+			FlagString:  "warningPercentUsedBtrfsData",
+			Description: "Warning threshold for the used btrfs data space in percent of the allocated and unallocated space",
+		},
+		{
+			Th:          &FsConfig.Btrfs.DataUsage.Crit,
+			FlagString:  "criticalPercentUsedBtrfsData",
+			Description: "Critical threshold for the used btrfs data space in percent of the allocated and unallocated space",
+		},
+		{
+			Th:          &FsConfig.Btrfs.MetadataUsage.Warn,
+			FlagString:  "warningPercentUsedBtrfsMetadata",
+			Description: "Warning threshold for the used btrfs metadata space in percent of the allocated and unallocated space",
+		},
+		{
+			Th:          &FsConfig.Btrfs.MetadataUsage.Crit,
+			FlagString:  "criticalPercentUsedBtrfsMetadata",
+			Description: "Critical threshold for the used btrfs metadata space in percent of the allocated and unallocated space",
+		},
+		{
+			Th:          &FsConfig.Btrfs.DeviceErrors.Warn,
+			FlagString:  "warningBtrfsDeviceErrors",
+			Description: "Warning threshold for the sum of the error counters of a btrfs device",
+		},
+		{
+			Th:          &FsConfig.Btrfs.DeviceErrors.Crit,
+			FlagString:  "criticalBtrfsDeviceErrors",
+			Description: "Critical threshold for the sum of the error counters of a btrfs device",
+		},
 		{
 			Th:          &FsConfig.WarningTotalCountOfFs,
 			FlagString:  "warningTotalCountOfMatches",
@@ -650,11 +1174,17 @@ func init() {
 	// Thresholds
 	thresholds.AddFlags(fs, &fsThresholds)
 
+	fs.Var(&FsConfig.ThresholdRules, "threshold-rule",
+		"Replace the thresholds for the filesystems selected by the regular expressions 'mount-path', 'device-path' and/or 'fs-type' (may be repeated).\n"+
+			"The keys are the names of the threshold flags, thresholds not given in the rule are not evaluated. An optional 'name' is shown in the output.\n"+
+			"If several rules match, the most specific one wins (mount-path before device-path before fs-type). "+
+			"The key=value pairs of a rule are separated by whitespace. E.g. 'name=data mount-path=^/data$ warningPercentUsedSpace=95 criticalPercentUsedSpace=98'")
+
 	// TODO change this directly to the regex expression type
 	fs.StringSliceVar(&FsConfig.Filters.ExcludeFsType, "exclude-fs-type", nil,
-		"Ignore all filesystems of indicated type (may be repeated). E.g. 'zfs', 'apfs'\nNote: The same thresholds will be applied to all filesystems")
+		"Ignore all filesystems of indicated type (may be repeated). E.g. 'zfs', 'apfs'\nNote: The same thresholds will be applied to all filesystems unless a threshold rule matches")
 	fs.StringSliceVar(&FsConfig.Filters.IncludeFsType, "include-fs-type", IncludeFsTypeDefaults,
-		"Explicitly include only filesystems of indicated type (may be repeated). E.g. 'zfs', 'apfs'\nNote: The same thresholds will be applied to all filesystems")
+		"Explicitly include only filesystems of indicated type (may be repeated). E.g. 'zfs', 'apfs'\nNote: The same thresholds will be applied to all filesystems unless a threshold rule matches")
 
 	fs.StringSliceVar(&FsConfig.Filters.ExcludeDevicePaths, "exclude-device-path", nil,
 		"Ignore the given device path regex (may be repeated). E.g. '/dev/sd.*'")
@@ -676,7 +1206,36 @@ func init() {
 	fs.BoolVar(&FsConfig.ReadWriteOption, "readwrite-filesystems", false,
 		"Only list filesystem mounted as readwrite. This is just a convenient shorthand for \"--include-mount-options '^rw$'\"")
 
+	fs.BoolVar(&FsConfig.CheckFstab, "check-fstab", false,
+		"Compare the mounted filesystems with the fstab. Entries which are not mounted (except noauto and swap) are CRITICAL, "+
+			"mounts whose type or options differ and mounts not in the fstab (after applying the filters) are WARNING")
+	fs.StringVar(&FsConfig.FstabPath, "fstab", filesystem.DefaultFstabPath,
+		"The path of the fstab used by --check-fstab")
+
+	fs.BoolVar(&FsConfig.Btrfs.Check, "check-btrfs", false,
+		"Report the data and metadata allocation and the device errors of btrfs filesystems. This is implied by the btrfs thresholds")
+	fs.BoolVar(&FsConfig.CheckRoRemount, "check-ro-remount", false,
+		"Alert filesystems mounted read-only which are configured read-write in the fstab as CRITICAL (e.g. after ext4 or xfs remounted them due to errors). "+
+			"The error counters of ext4 and the error settings of xfs are shown as well")
+	fs.StringSliceVar(&FsConfig.ExpectedRWMountPaths, "expected-rw-mount-path", nil,
+		"Alert filesystems mounted read-only whose mount path matches this regex as CRITICAL, in addition to the fstab (may be repeated). E.g. '^/srv/'")
+
+	fs.IntVar(&FsConfig.Workers, "workers", filesystem.DefaultWorkers,
+		"The number of filesystems which are queried concurrently")
+	fs.DurationVar(&FsConfig.MountTimeout, "mount-timeout", filesystem.DefaultMountTimeout,
+		"The time a single filesystem may take to answer. All filesystems together must answer within half of the --timeout")
+	fs.StringVar(&FsConfig.HungStateOption, "hung-state", check.UnknownString,
+		"The state of filesystems which do not answer in time (UNKNOWN, WARNING or CRITICAL)")
+
+	fs.DurationVar(&FsConfig.Forecast.Window, "forecast-window", 0,
+		"Record the usage in the state between executions and forecast the time until the filesystems are full by a linear regression over the samples of this time span, "+
+			"e.g. '24h'. The forecast is disabled by default")
+	fs.StringVar(&FsConfig.Forecast.Unit, "forecast-unit", filesystem.ForecastUnitHours,
+		"The unit of the time to full thresholds, either 'hours' or 'days'")
+
 	fs.SortFlags = false
+
+	registerCheck(diskCmd, filesystemCheck)
 }
 
 func validateOptions(config *filesystem.CheckConfig) error {
@@ -684,6 +1243,32 @@ func validateOptions(config *filesystem.CheckConfig) error {
 		return errors.New("readonly and readwrite options are mutually exclusive. Please remove one of them")
 	}
 
+	if config.Workers < 1 {
+		return errors.New("at least one worker is needed to query the filesystems")
+	}
+
+	if config.MountTimeout <= 0 {
+		return errors.New("the mount timeout must be positive")
+	}
+
+	hungState, err := check.NewStatusFromString(config.HungStateOption)
+	if err != nil {
+		return err
+	}
+
+	if hungState == check.OK {
+		return errors.New("the state of hung filesystems must be UNKNOWN, WARNING or CRITICAL")
+	}
+
+	config.HungState = hungState
+
+	if config.Forecast.Enabled() {
+		_, err = config.Forecast.UnitSeconds()
+		if err != nil {
+			return err
+		}
+	}
+
 	if config.ReadonlyOption {
 		config.Filters.IncludeOptions = append(config.Filters.IncludeOptions, "^ro$")
 	}
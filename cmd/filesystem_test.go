@@ -2,7 +2,9 @@ package cmd
 
 import (
 	"testing"
+	"time"
 
+	"github.com/NETWAYS/check_system_basics/internal/common/state"
 	"github.com/NETWAYS/check_system_basics/internal/common/thresholds"
 	"github.com/NETWAYS/check_system_basics/internal/filesystem"
 
@@ -68,3 +70,280 @@ func TestFsCheckResult1(t *testing.T) {
 		t.Fatalf("expected %v, got %v", check.Warning, result.GetStatus())
 	}
 }
+
+func TestFsCheckResultWithRule(t *testing.T) {
+	config := filesystem.CheckConfig{}
+
+	// 50% free space would be critical without the rule
+	err := config.CriticalPercentThreshold.Space.Free.Set("60:")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	err = config.ThresholdRules.Set("name=test mount-path=^/testMountpoint$ criticalPercentUsedSpace=95")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	fsConfig, rule := config.ForFilesystem(&testFsHalfFull)
+
+	result := computeFsCheckResult(&testFsHalfFull, fsConfig)
+
+	if check.OK != result.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.OK, result.GetStatus())
+	}
+
+	expected := "/testMountpoint (50.00% used space, 50.00% free inodes, rule: test)"
+	if output := fsOutput(&testFsHalfFull, rule); output != expected {
+		t.Fatalf("expected %v, got %v", expected, output)
+	}
+}
+
+func TestFsForecast(t *testing.T) {
+	config := filesystem.ForecastConfig{
+		Window: 24 * time.Hour,
+		Unit:   filesystem.ForecastUnitHours,
+	}
+
+	err := config.SpaceTimeToFull.Warn.Set("24:")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	start := time.Unix(1700000000, 0)
+
+	// 2 MiB are free and 256 KiB are added per hour
+	samples := []state.Sample{
+		{Timestamp: start, Value: 1572864},
+		{Timestamp: start.Add(time.Hour), Value: 1835008},
+		{Timestamp: start.Add(2 * time.Hour), Value: 2097152},
+	}
+
+	result := computeFsForecast(&testFsHalfFull, "space", 4194304, samples, &config.SpaceTimeToFull, &config, 3600)
+
+	if check.Warning != result.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.Warning, result.GetStatus())
+	}
+
+	expected := "[WARNING] Space forecast: growing by 6MiB per day, full in 8.0 hours violates threshold"
+	if result.String() != expected {
+		t.Fatalf("expected %v, got %v", expected, result.String())
+	}
+
+	result = computeFsForecast(&testFsHalfFull, "space", 4194304, samples[:2], &config.SpaceTimeToFull, &config, 3600)
+
+	if check.OK != result.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.OK, result.GetStatus())
+	}
+
+	// Shrinking usage is never full
+	shrinking := []state.Sample{
+		{Timestamp: start, Value: 2097152},
+		{Timestamp: start.Add(time.Hour), Value: 1835008},
+		{Timestamp: start.Add(2 * time.Hour), Value: 1572864},
+	}
+
+	result = computeFsForecast(&testFsHalfFull, "space", 4194304, shrinking, &config.SpaceTimeToFull, &config, 3600)
+
+	expected = "[OK] Space forecast: shrinking by 6MiB per day, not growing"
+	if result.String() != expected {
+		t.Fatalf("expected %v, got %v", expected, result.String())
+	}
+}
+
+func TestFsForecastReservedBlocks(t *testing.T) {
+	config := filesystem.ForecastConfig{
+		Window: 24 * time.Hour,
+		Unit:   filesystem.ForecastUnitHours,
+	}
+
+	// 1 MiB of the 4 MiB is reserved for root, only 1 MiB is free for the applications
+	fs := testFsHalfFull
+	fs.UsageStats.Free = 1048576
+
+	capacity := fsSpaceCapacity(&fs)
+	if capacity != 3145728 {
+		t.Fatalf("expected %v, got %v", 3145728, capacity)
+	}
+
+	start := time.Unix(1700000000, 0)
+
+	samples := []state.Sample{
+		{Timestamp: start, Value: 1572864},
+		{Timestamp: start.Add(time.Hour), Value: 1835008},
+		{Timestamp: start.Add(2 * time.Hour), Value: 2097152},
+	}
+
+	result := computeFsForecast(&fs, "space", float64(capacity), samples, &config.SpaceTimeToFull, &config, 3600)
+
+	expected := "[OK] Space forecast: growing by 6MiB per day, full in 4.0 hours"
+	if result.String() != expected {
+		t.Fatalf("expected %v, got %v", expected, result.String())
+	}
+}
+
+func TestFsCheckResultHung(t *testing.T) {
+	config := filesystem.CheckConfig{
+		Workers:         1,
+		MountTimeout:    time.Second,
+		HungStateOption: check.CriticalString,
+	}
+
+	err := validateOptions(&config)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	hung := testFsHalfFull
+	hung.Error = filesystem.ErrHung
+
+	result := computeFsCheckResult(&hung, &config)
+
+	if check.Critical != result.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.Critical, result.GetStatus())
+	}
+
+	notQueried := testFsHalfFull
+	notQueried.Error = filesystem.ErrNotQueried
+
+	result = computeFsCheckResult(&notQueried, &config)
+
+	if check.Critical != result.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.Critical, result.GetStatus())
+	}
+
+	config.HungStateOption = check.OKString
+
+	err = validateOptions(&config)
+	if err == nil {
+		t.Fatal("expected an error for OK as state of hung filesystems")
+	}
+}
+
+func TestFstabResult(t *testing.T) {
+	result := computeFstabResult(filesystem.FstabComparison{})
+
+	if check.OK != result.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.OK, result.GetStatus())
+	}
+
+	comparison := filesystem.FstabComparison{
+		Mismatches: []filesystem.FstabMismatch{
+			{
+				Entry:       filesystem.FstabEntry{Mountpoint: "/testMountpoint"},
+				Mount:       testFsHalfFull,
+				Differences: []string{"option ro instead of rw"},
+			},
+		},
+	}
+
+	result = computeFstabResult(comparison)
+
+	if check.Warning != result.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.Warning, result.GetStatus())
+	}
+
+	comparison.NotMounted = []filesystem.FstabEntry{{Device: "/dev/sdb1", Mountpoint: "/srv"}}
+
+	result = computeFstabResult(comparison)
+
+	if check.Critical != result.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.Critical, result.GetStatus())
+	}
+
+	expected := "[CRITICAL] fstab: 1 not mounted, 1 differing, 0 unexpected"
+	if result.String() != expected {
+		t.Fatalf("expected %v, got %v", expected, result.String())
+	}
+}
+
+func TestRoRemount(t *testing.T) {
+	readOnly := testFsHalfFull
+	readOnly.PartStats.Fstype = "ext4"
+	readOnly.PartStats.Opts = []string{"ro", "relatime"}
+
+	kernelErrors := []filesystem.KernelError{
+		{Name: "errors_count", Value: 2},
+		{Name: "first_error_time", Value: 1700000000},
+		{Name: "last_error_time", Value: 0},
+	}
+
+	result := computeRoRemount(&readOnly, true, kernelErrors)
+
+	expected := "[CRITICAL] Mounted read-only, but configured read-write (ext4 errors_count=2, first_error_time=2023-11-14T22:13:20Z, last_error_time=never)"
+	if result.String() != expected {
+		t.Fatalf("expected %v, got %v", expected, result.String())
+	}
+
+	result = computeRoRemount(&readOnly, false, kernelErrors)
+
+	if check.OK != result.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.OK, result.GetStatus())
+	}
+
+	if computeRoRemount(&testFsHalfFull, false, nil) != nil {
+		t.Fatal("expected no result for a filesystem without expectation and counters")
+	}
+
+	// An error only affects this filesystem
+	config := filesystem.CheckConfig{ExpectedRWMountPaths: []string{"("}}
+
+	result = checkRoRemount(&testFsHalfFull, nil, &config)
+
+	if check.Unknown != result.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.Unknown, result.GetStatus())
+	}
+}
+
+func TestBtrfs(t *testing.T) {
+	config := filesystem.BtrfsConfig{}
+
+	for th, value := range map[*thresholds.ThresholdWrapper]string{
+		&config.MetadataUsage.Warn: "90",
+		&config.MetadataUsage.Crit: "95",
+		&config.DeviceErrors.Warn:  "0",
+	} {
+		err := th.Set(value)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	// 1 GiB of metadata in DUP is allocated and almost used up, only 128 MiB are unallocated
+	info := filesystem.BtrfsInfo{
+		Allocations: []filesystem.BtrfsAllocation{
+			{Type: filesystem.BtrfsData, TotalBytes: 8 << 30, BytesUsed: 4 << 30, DiskTotal: 8 << 30, DiskUsed: 4 << 30},
+			{Type: filesystem.BtrfsMetadata, TotalBytes: 1 << 30, BytesUsed: 1<<30 - 1<<20, DiskTotal: 2 << 30, DiskUsed: 2<<30 - 2<<20},
+			{Type: filesystem.BtrfsSystem, TotalBytes: 8 << 20, BytesUsed: 16 << 10, DiskTotal: 16 << 20, DiskUsed: 32 << 10},
+		},
+		Size:        11<<30 + 16<<20,
+		Unallocated: 128 << 20,
+		Devices: []filesystem.BtrfsDevice{
+			{ID: "1", Errors: map[string]uint64{"read_errs": 0, "write_errs": 0}},
+		},
+	}
+
+	result := computeBtrfs(&testFsHalfFull, &info, &config)
+
+	// 1023 MiB used of 1088 MiB usable
+	if check.Warning != result.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.Warning, result.GetStatus())
+	}
+
+	info.Unallocated = 0
+
+	result = computeBtrfs(&testFsHalfFull, &info, &config)
+
+	if check.Critical != result.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.Critical, result.GetStatus())
+	}
+
+	info.Unallocated = 100 << 30
+	info.Devices[0].Errors["read_errs"] = 2
+
+	result = computeBtrfs(&testFsHalfFull, &info, &config)
+
+	if check.Warning != result.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.Warning, result.GetStatus())
+	}
+}
@@ -24,151 +24,147 @@ var loadCmd = &cobra.Command{
 \_ [OK] 5 minute average: 0.21
 \_ [OK] 15 minute average: 0.25
 |load1=0.1;2;;0 load5=0.21;;;0 load15=0.25;;;0`,
-	Run: func(_ *cobra.Command, _ []string) {
-		loadStats, err := load.GetActualLoadValues()
-		if err != nil {
-			check.ExitError(err)
-		}
+	Run: runCheck(loadCheck),
+}
 
-		cpuCount, err := cpu.Counts(true)
-		if err != nil {
-			check.ExitError(fmt.Errorf("could not get CPU count: %w", err))
+func loadCheck(_ *cobra.Command) ([]*result.PartialResult, error) {
+	loadStats, err := load.GetActualLoadValues()
+	if err != nil {
+		return nil, err
+	}
+
+	cpuCount, err := cpu.Counts(true)
+	if err != nil {
+		return nil, fmt.Errorf("could not get CPU count: %w", err)
+	}
+
+	var originalLoad [3]float64
+
+	if LoadConfig.PerCPU {
+		originalLoad[0] = loadStats.LoadAvg.Load1
+		loadStats.LoadAvg.Load1 /= float64(cpuCount)
+		originalLoad[1] = loadStats.LoadAvg.Load5
+		loadStats.LoadAvg.Load5 /= float64(cpuCount)
+		originalLoad[2] = loadStats.LoadAvg.Load15
+		loadStats.LoadAvg.Load15 /= float64(cpuCount)
+	}
+
+	// 1 Minute average
+	partialLoad1 := result.NewPartialResult()
+
+	partialLoad1.SetDefaultState(check.OK)
+
+	// TODO Use strings.Builder
+	tmpOutput := fmt.Sprintf("1 minute average: %.2f", loadStats.LoadAvg.Load1)
+	tmpPerfdata := &check.Perfdata{
+		Label: "load1",
+		Value: loadStats.LoadAvg.Load1,
+		Min:   0,
+		Max:   nil,
+	}
+
+	if LoadConfig.Load1Th.Crit.IsSet {
+		tmpPerfdata.Crit = &LoadConfig.Load1Th.Crit.Th
+		if LoadConfig.Load1Th.Crit.Th.DoesViolate(loadStats.LoadAvg.Load1) {
+			partialLoad1.SetState(check.Critical)
+
+			tmpOutput += critThresMsg
 		}
+	} else if LoadConfig.Load1Th.Warn.IsSet {
+		tmpPerfdata.Warn = &LoadConfig.Load1Th.Warn.Th
+		if LoadConfig.Load1Th.Warn.Th.DoesViolate(loadStats.LoadAvg.Load1) {
+			partialLoad1.SetState(check.Warning)
 
-		var originalLoad [3]float64
-
-		if LoadConfig.PerCPU {
-			originalLoad[0] = loadStats.LoadAvg.Load1
-			loadStats.LoadAvg.Load1 /= float64(cpuCount)
-			originalLoad[1] = loadStats.LoadAvg.Load5
-			loadStats.LoadAvg.Load5 /= float64(cpuCount)
-			originalLoad[2] = loadStats.LoadAvg.Load15
-			loadStats.LoadAvg.Load15 /= float64(cpuCount)
+			tmpOutput += warnThresMsg
 		}
+	} else {
+		partialLoad1.SetState(check.OK)
+	}
 
-		var overall result.Overall
+	if LoadConfig.PerCPU {
+		tmpOutput += fmt.Sprintf(", system total: %.2f", originalLoad[0])
+	}
 
-		// 1 Minute average
-		partialLoad1 := result.NewPartialResult()
+	partialLoad1.SetOutput(tmpOutput)
+	partialLoad1.AddPerfdata(tmpPerfdata)
 
-		partialLoad1.SetDefaultState(check.OK)
+	// 5 Minute average
+	partialLoad5 := result.NewPartialResult()
 
-		// TODO Use strings.Builder
-		tmpOutput := fmt.Sprintf("1 minute average: %.2f", loadStats.LoadAvg.Load1)
-		tmpPerfdata := &check.Perfdata{
-			Label: "load1",
-			Value: loadStats.LoadAvg.Load1,
-			Min:   0,
-			Max:   nil,
-		}
+	partialLoad5.SetDefaultState(check.OK)
 
-		if LoadConfig.Load1Th.Crit.IsSet {
-			tmpPerfdata.Crit = &LoadConfig.Load1Th.Crit.Th
-			if LoadConfig.Load1Th.Crit.Th.DoesViolate(loadStats.LoadAvg.Load1) {
-				partialLoad1.SetState(check.Critical)
-
-				tmpOutput += critThresMsg
-			}
-		} else if LoadConfig.Load1Th.Warn.IsSet {
-			tmpPerfdata.Warn = &LoadConfig.Load1Th.Warn.Th
-			if LoadConfig.Load1Th.Warn.Th.DoesViolate(loadStats.LoadAvg.Load1) {
-				partialLoad1.SetState(check.Warning)
-
-				tmpOutput += warnThresMsg
-			}
-		} else {
-			partialLoad1.SetState(check.OK)
-		}
-
-		if LoadConfig.PerCPU {
-			tmpOutput += fmt.Sprintf(", system total: %.2f", originalLoad[0])
-		}
+	tmpOutput = fmt.Sprintf("5 minute average: %.2f", loadStats.LoadAvg.Load5)
+	tmpPerfdata = &check.Perfdata{
+		Label: "load5",
+		Value: loadStats.LoadAvg.Load5,
+		Min:   0,
+		Max:   nil,
+	}
 
-		partialLoad1.SetOutput(tmpOutput)
-		partialLoad1.AddPerfdata(tmpPerfdata)
+	if LoadConfig.Load5Th.Crit.IsSet {
+		tmpPerfdata.Crit = &LoadConfig.Load5Th.Crit.Th
+		if LoadConfig.Load5Th.Crit.Th.DoesViolate(loadStats.LoadAvg.Load5) {
+			partialLoad5.SetState(check.Critical)
 
-		// 5 Minute average
-		partialLoad5 := result.NewPartialResult()
-
-		partialLoad5.SetDefaultState(check.OK)
-
-		tmpOutput = fmt.Sprintf("5 minute average: %.2f", loadStats.LoadAvg.Load5)
-		tmpPerfdata = &check.Perfdata{
-			Label: "load5",
-			Value: loadStats.LoadAvg.Load5,
-			Min:   0,
-			Max:   nil,
+			tmpOutput += critThresMsg
 		}
+	} else if LoadConfig.Load5Th.Warn.IsSet {
+		tmpPerfdata.Warn = &LoadConfig.Load5Th.Warn.Th
+		if LoadConfig.Load5Th.Warn.Th.DoesViolate(loadStats.LoadAvg.Load5) {
+			partialLoad5.SetState(check.Warning)
 
-		if LoadConfig.Load5Th.Crit.IsSet {
-			tmpPerfdata.Crit = &LoadConfig.Load5Th.Crit.Th
-			if LoadConfig.Load5Th.Crit.Th.DoesViolate(loadStats.LoadAvg.Load5) {
-				partialLoad5.SetState(check.Critical)
-
-				tmpOutput += critThresMsg
-			}
-		} else if LoadConfig.Load5Th.Warn.IsSet {
-			tmpPerfdata.Warn = &LoadConfig.Load5Th.Warn.Th
-			if LoadConfig.Load5Th.Warn.Th.DoesViolate(loadStats.LoadAvg.Load5) {
-				partialLoad5.SetState(check.Warning)
-
-				tmpOutput += warnThresMsg
-			}
-		} else {
-			partialLoad5.SetState(check.OK)
+			tmpOutput += warnThresMsg
 		}
+	} else {
+		partialLoad5.SetState(check.OK)
+	}
 
-		if LoadConfig.PerCPU {
-			tmpOutput += fmt.Sprintf(", system total: %.2f", originalLoad[1])
-		}
+	if LoadConfig.PerCPU {
+		tmpOutput += fmt.Sprintf(", system total: %.2f", originalLoad[1])
+	}
 
-		partialLoad5.SetOutput(tmpOutput)
-		partialLoad5.AddPerfdata(tmpPerfdata)
+	partialLoad5.SetOutput(tmpOutput)
+	partialLoad5.AddPerfdata(tmpPerfdata)
 
-		// 15 Minute average
-		partialLoad15 := result.NewPartialResult()
+	// 15 Minute average
+	partialLoad15 := result.NewPartialResult()
 
-		partialLoad15.SetDefaultState(check.OK)
+	partialLoad15.SetDefaultState(check.OK)
 
-		tmpOutput = fmt.Sprintf("15 minute average: %.2f", loadStats.LoadAvg.Load15)
-		tmpPerfdata = &check.Perfdata{
-			Label: "load15",
-			Value: loadStats.LoadAvg.Load15,
-			Min:   0,
-			Max:   nil,
-		}
+	tmpOutput = fmt.Sprintf("15 minute average: %.2f", loadStats.LoadAvg.Load15)
+	tmpPerfdata = &check.Perfdata{
+		Label: "load15",
+		Value: loadStats.LoadAvg.Load15,
+		Min:   0,
+		Max:   nil,
+	}
+
+	if LoadConfig.Load15Th.Crit.IsSet {
+		tmpPerfdata.Crit = &LoadConfig.Load15Th.Crit.Th
+		if LoadConfig.Load15Th.Crit.Th.DoesViolate(loadStats.LoadAvg.Load15) {
+			partialLoad15.SetState(check.Critical)
 
-		if LoadConfig.Load15Th.Crit.IsSet {
-			tmpPerfdata.Crit = &LoadConfig.Load15Th.Crit.Th
-			if LoadConfig.Load15Th.Crit.Th.DoesViolate(loadStats.LoadAvg.Load15) {
-				partialLoad15.SetState(check.Critical)
-
-				tmpOutput += critThresMsg
-			}
-		} else if LoadConfig.Load15Th.Warn.IsSet {
-			tmpPerfdata.Warn = &LoadConfig.Load15Th.Warn.Th
-			if LoadConfig.Load15Th.Warn.Th.DoesViolate(loadStats.LoadAvg.Load15) {
-				partialLoad15.SetState(check.Warning)
-
-				tmpOutput += warnThresMsg
-			}
-		} else {
-			partialLoad15.SetState(check.OK)
+			tmpOutput += critThresMsg
 		}
+	} else if LoadConfig.Load15Th.Warn.IsSet {
+		tmpPerfdata.Warn = &LoadConfig.Load15Th.Warn.Th
+		if LoadConfig.Load15Th.Warn.Th.DoesViolate(loadStats.LoadAvg.Load15) {
+			partialLoad15.SetState(check.Warning)
 
-		if LoadConfig.PerCPU {
-			tmpOutput += fmt.Sprintf(", system total: %.2f", originalLoad[2])
+			tmpOutput += warnThresMsg
 		}
+	} else {
+		partialLoad15.SetState(check.OK)
+	}
 
-		partialLoad15.SetOutput(tmpOutput)
-		partialLoad15.AddPerfdata(tmpPerfdata)
+	if LoadConfig.PerCPU {
+		tmpOutput += fmt.Sprintf(", system total: %.2f", originalLoad[2])
+	}
 
-		overall.AddSubcheck(partialLoad1)
-		overall.AddSubcheck(partialLoad5)
-		overall.AddSubcheck(partialLoad15)
+	partialLoad15.SetOutput(tmpOutput)
+	partialLoad15.AddPerfdata(tmpPerfdata)
 
-		check.Exit(overall.GetStatus(), overall.GetOutput())
-	},
+	return []*result.PartialResult{partialLoad1, partialLoad5, partialLoad15}, nil
 }
 
 func init() {
@@ -187,4 +183,6 @@ func init() {
 		"Divide the load averages by the number of CPUs")
 
 	loadFs.SortFlags = false
+
+	registerCheck(loadCmd, loadCheck)
 }
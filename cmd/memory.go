@@ -1,8 +1,13 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"slices"
+	"strings"
 
+	"github.com/NETWAYS/check_system_basics/internal/common/state"
 	"github.com/NETWAYS/check_system_basics/internal/common/thresholds"
 	"github.com/NETWAYS/check_system_basics/internal/memory"
 	"github.com/NETWAYS/go-check"
@@ -25,28 +30,479 @@ var memoryCmd = &cobra.Command{
 \_ [OK] Swap Usage 0.00% (0 B / 36 GiB)
 |available_memory_percentage=74.36%;15:100;5:100 available_memory=24856633344B;10:20;;0;33427595264 free_memory=16696102912B;;;0;33427595264 free_memory_percentage=49.947%;;50:80 used_memory=6542696448B;@10;;0;33427595264 free_memory_percentage=19.573% swap_usage_percent=0%;20;85 swap_used=0B;;;0;38654701568
 `,
-	Run: func(_ *cobra.Command, _ []string) {
-		var overall result.Overall
+	Run: runCheck(memoryCheck),
+}
+
+func memoryCheck(cmd *cobra.Command) ([]*result.PartialResult, error) {
+	// ## RAM stuff
+	memStats, err := memory.LoadMemStat()
+	if err != nil {
+		return nil, err
+	}
+
+	cgroup, err := loadCgroup(&MemoryConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// In a cgroup the same thresholds are evaluated against its limit
+	if cgroup != nil {
+		memStats = cgroup.Mem(memStats)
+	}
 
-		// ## RAM stuff
-		memStats, err := memory.LoadMemStat()
+	// Memory stuff
+	memResult := computeMemResults(&MemoryConfig, memStats)
+	results := []*result.PartialResult{memResult}
+
+	if cgroup != nil {
+		memResult.SetOutput("RAM of cgroup " + cgroup.Path)
+		results = append(results, computeCgroupResults(cgroup))
+	}
+
+	// Swap stuff
+	if memStats.VirtMem.SwapTotal != 0 {
+		results = append(results, computeSwapResults(memStats))
+	}
+
+	// Committed memory
+	if MemoryConfig.CommittedMemory || MemoryConfig.CommittedPercentage.IsSet() {
+		overcommit, err := memory.ReadOvercommit(memStats.Meminfo)
 		if err != nil {
-			check.ExitError(err)
+			return nil, err
 		}
 
-		// Memory stuff
-		partialMem := computeMemResults(&MemoryConfig, memStats)
+		results = append(results, computeCommittedResults(&MemoryConfig, overcommit))
+	}
 
-		overall.AddSubcheck(partialMem)
+	// Counters compared with the previous execution
+	trackOOMKills := MemoryConfig.TrackOOMKills || len(MemoryConfig.OOMCgroups) > 0
+	swapActivity := MemoryConfig.SwapActivity || MemoryConfig.SwapInRate.IsSet() ||
+		MemoryConfig.SwapOutRate.IsSet() || MemoryConfig.MajorFaultRate.IsSet()
 
-		// Swap stuff
-		if memStats.VirtMem.SwapTotal != 0 {
-			partSwap := computeSwapResults(memStats)
-			overall.AddSubcheck(partSwap)
+	if trackOOMKills || swapActivity {
+		vmstat, err := memory.ReadVmstat()
+		if err != nil {
+			return nil, fmt.Errorf("could not load vmstat: %w", err)
+		}
+
+		// Without the state only the counters are UNKNOWN, the other results are still reported
+		store, err := openStateStore(cmd, "memory")
+		if err != nil {
+			if swapActivity {
+				results = append(results, memoryStateUnknown("Swap activity", err))
+			}
+
+			if trackOOMKills {
+				results = append(results, memoryStateUnknown("OOM kills", err))
+			}
+		} else {
+			if swapActivity {
+				results = append(results, computeSwapActivityResults(store, vmstat, &MemoryConfig))
+			}
+
+			if trackOOMKills {
+				results = append(results, computeOOMResults(store, vmstat, &MemoryConfig))
+			}
+
+			err = store.Close()
+			if err != nil {
+				results = append(results, stateNotSaved(err))
+			}
 		}
+	}
+
+	// Meminfo breakdown
+	err = validateMeminfoOptions(&MemoryConfig, memStats.Meminfo)
+	if err != nil {
+		return nil, err
+	}
+
+	if meminfoResult := computeMeminfoResults(&MemoryConfig, memStats.Meminfo); meminfoResult != nil {
+		results = append(results, meminfoResult)
+	}
+
+	return results, nil
+}
+
+// memoryStateUnknown reports that the counters of name can not be compared with the previous execution
+func memoryStateUnknown(name string, err error) *result.PartialResult {
+	partial := result.NewPartialResult()
+	partial.SetState(check.Unknown)
+	partial.SetOutput(fmt.Sprintf("%s not available, the state of the previous execution could not be read: %s", name, err))
 
-		check.Exit(overall.GetStatus(), overall.GetOutput())
-	},
+	return partial
+}
+
+// loadCgroup returns the cgroup given in the configuration or the detected cgroup of the container
+// with a memory limit, if any. It returns nil to check the host. Only an explicitly given cgroup
+// which can not be read is an error, missing or inaccessible cgroup files during the detection
+// (e.g. without /proc or in a restricted sandbox) fall back to the host
+func loadCgroup(config *memory.MemConfig) (*memory.Cgroup, error) {
+	if config.Cgroup != "" {
+		return memory.ReadCgroup(config.Cgroup)
+	}
+
+	if config.NoCgroupDetection {
+		return nil, nil
+	}
+
+	cgroup, err := memory.DetectCgroup()
+	if errors.Is(err, os.ErrNotExist) || errors.Is(err, os.ErrPermission) {
+		return nil, nil
+	}
+
+	return cgroup, err
+}
+
+// computeCgroupResults reports the usage and the limits of a cgroup v2. Exceeding memory.high
+// results in a warning, as the processes of the cgroup are throttled and reclaimed then
+func computeCgroupResults(cgroup *memory.Cgroup) *result.PartialResult {
+	partialCgroup := result.NewPartialResult()
+	partialCgroup.SetDefaultState(check.OK)
+
+	pdCurrent := check.Perfdata{
+		Label: "cgroup_memory_current",
+		Value: cgroup.Current,
+		Uom:   "B",
+		Min:   0,
+	}
+
+	output := fmt.Sprintf("cgroup %s: memory.current %s", cgroup.Path, convert.BytesIEC(cgroup.Current))
+
+	if cgroup.Max != nil {
+		output += " of memory.max " + convert.BytesIEC(*cgroup.Max)
+		pdCurrent.Max = *cgroup.Max
+	} else {
+		output += ", memory.max unlimited"
+	}
+
+	partialCgroup.AddPerfdata(&pdCurrent)
+
+	if cgroup.High != nil {
+		output += ", memory.high " + convert.BytesIEC(*cgroup.High)
+
+		if cgroup.Current >= *cgroup.High {
+			output += " exceeded, the cgroup is throttled"
+
+			partialCgroup.SetState(check.Warning)
+		}
+
+		partialCgroup.AddPerfdata(&check.Perfdata{
+			Label: "cgroup_memory_high",
+			Value: *cgroup.High,
+			Uom:   "B",
+			Min:   0,
+		})
+	}
+
+	output += fmt.Sprintf(", anon %s, file %s", convert.BytesIEC(cgroup.Anon), convert.BytesIEC(cgroup.File))
+
+	partialCgroup.AddPerfdata(&check.Perfdata{
+		Label: "cgroup_anon",
+		Value: cgroup.Anon,
+		Uom:   "B",
+		Min:   0,
+	})
+	partialCgroup.AddPerfdata(&check.Perfdata{
+		Label: "cgroup_file",
+		Value: cgroup.File,
+		Uom:   "B",
+		Min:   0,
+	})
+
+	if cgroup.SwapAccounting {
+		pdSwap := check.Perfdata{
+			Label: "cgroup_swap_current",
+			Value: cgroup.SwapCurrent,
+			Uom:   "B",
+			Min:   0,
+		}
+
+		output += ", swap " + convert.BytesIEC(cgroup.SwapCurrent)
+
+		if cgroup.SwapMax != nil {
+			output += " of swap.max " + convert.BytesIEC(*cgroup.SwapMax)
+			pdSwap.Max = *cgroup.SwapMax
+		} else {
+			output += ", swap.max unlimited"
+		}
+
+		partialCgroup.AddPerfdata(&pdSwap)
+	}
+
+	partialCgroup.SetOutput(output)
+
+	return partialCgroup
+}
+
+// computeCommittedResults evaluates the committed memory in relation to the CommitLimit
+// and explains how the limit is derived in the current overcommit mode
+func computeCommittedResults(config *memory.MemConfig, overcommit *memory.Overcommit) *result.PartialResult {
+	partialCommitted := result.NewPartialResult()
+	partialCommitted.SetDefaultState(check.OK)
+
+	committedPercentage := overcommit.CommittedPercentage()
+
+	pdCommitted := check.Perfdata{
+		Label: "committed_memory",
+		Value: overcommit.CommittedAS,
+		Uom:   "B",
+		Min:   0,
+	}
+
+	pdCommitLimit := check.Perfdata{
+		Label: "commit_limit",
+		Value: overcommit.CommitLimit,
+		Uom:   "B",
+		Min:   0,
+	}
+
+	pdCommittedPercentage := check.Perfdata{
+		Label: "committed_memory_percentage",
+		Value: committedPercentage,
+		Uom:   "%",
+	}
+
+	// Without the enforcement the committed memory may exceed the limit
+	if overcommit.Enforced() {
+		pdCommitted.Max = overcommit.CommitLimit
+	}
+
+	partialCommitted.SetState(config.CommittedPercentage.Evaluate(committedPercentage, &pdCommittedPercentage))
+
+	output := fmt.Sprintf("Committed Memory %.2f%% (%s / %s)",
+		committedPercentage, convert.BytesIEC(overcommit.CommittedAS), convert.BytesIEC(overcommit.CommitLimit))
+
+	if partialCommitted.GetStatus() != check.OK {
+		output += " violates threshold"
+	}
+
+	partialCommitted.SetOutput(output + ", " + overcommit.Explain())
+
+	partialCommitted.AddPerfdata(&pdCommitted)
+	partialCommitted.AddPerfdata(&pdCommitLimit)
+
+	if config.PercentageInPerfdata {
+		partialCommitted.AddPerfdata(&pdCommittedPercentage)
+	}
+
+	return partialCommitted
+}
+
+// computeSwapActivityResults evaluates the rates of the pages swapped in and out and of the major page faults
+// since the previous execution, which show the actual swapping pressure unlike the swap usage
+func computeSwapActivityResults(store *state.Store, vmstat map[string]uint64, config *memory.MemConfig) *result.PartialResult {
+	partialActivity := result.NewPartialResult()
+	partialActivity.SetOutput("Swap Activity")
+	partialActivity.SetDefaultState(check.OK)
+
+	for _, counter := range []struct {
+		name        string
+		description string
+		thresholds  *thresholds.Thresholds
+	}{
+		{"pswpin", "Pages swapped in", &config.SwapInRate},
+		{"pswpout", "Pages swapped out", &config.SwapOutRate},
+		{"pgmajfault", "Major page faults", &config.MajorFaultRate},
+	} {
+		rate, err := store.Rate(counter.name, vmstat[counter.name])
+		partialActivity.AddSubcheck(computeVmstatRate(counter.description, counter.name, rate, err, counter.thresholds))
+	}
+
+	return partialActivity
+}
+
+// computeVmstatRate evaluates the rate of a counter of /proc/vmstat, err explains why the rate is not known
+func computeVmstatRate(description, name string, rate float64, err error, ths *thresholds.Thresholds) *result.PartialResult {
+	partialRate := result.NewPartialResult()
+
+	if err != nil {
+		partialRate.SetState(check.OK)
+		partialRate.SetOutput(fmt.Sprintf("%s: %s", description, state.Explain(err)))
+
+		return partialRate
+	}
+
+	pdRate := check.Perfdata{
+		Label: name + "_per_second",
+		Value: rate,
+		Min:   0,
+	}
+
+	partialRate.SetState(ths.Evaluate(rate, &pdRate))
+
+	if partialRate.GetStatus() == check.OK {
+		partialRate.SetOutput(fmt.Sprintf("%s: %.2f/s", description, rate))
+	} else {
+		partialRate.SetOutput(fmt.Sprintf("%s: %.2f/s violates threshold", description, rate))
+	}
+
+	partialRate.AddPerfdata(&pdRate)
+
+	return partialRate
+}
+
+// computeOOMResults evaluates the OOM kills since the previous execution, of the whole system
+// from /proc/vmstat and of the selected cgroups from their memory.events
+func computeOOMResults(store *state.Store, vmstat map[string]uint64, config *memory.MemConfig) *result.PartialResult {
+	partialOOM := result.NewPartialResult()
+	partialOOM.SetOutput("OOM Killer")
+	partialOOM.SetDefaultState(check.OK)
+
+	if killCount, ok := vmstat["oom_kill"]; !ok {
+		partialOOM.AddSubcheck(oomNotAvailable("system", "oom_kill is not available in /proc/vmstat (Linux 4.13 or newer needed)"))
+	} else {
+		kills, err := store.Delta("oom_kill", killCount)
+		partialOOM.AddSubcheck(computeOOMKills("system", "oom_kills", kills, nil, err, config))
+	}
+
+	for _, cgroup := range config.OOMCgroups {
+		events, err := memory.ReadMemoryEvents(cgroup)
+		if err != nil {
+			partialOOM.AddSubcheck(oomNotAvailable(cgroup, err.Error()))
+			continue
+		}
+
+		// Both counters are stored, even if one of the deltas can not be computed
+		kills, killsErr := store.Delta("cgroup_"+cgroup+"_oom_kill", events["oom_kill"])
+		oomEvents, oomErr := store.Delta("cgroup_"+cgroup+"_oom", events["oom"])
+
+		if killsErr == nil {
+			killsErr = oomErr
+		}
+
+		partialOOM.AddSubcheck(computeOOMKills(cgroup, "oom_kills_"+cgroup, kills, &oomEvents, killsErr, config))
+	}
+
+	return partialOOM
+}
+
+// computeOOMKills evaluates the number of OOM kills since the previous execution. oomEvents is the number of
+// times a cgroup hit its limit without reclaimable memory, it is nil for the system. err explains why the numbers
+// are not known
+func computeOOMKills(name, label string, kills uint64, oomEvents *uint64, err error, config *memory.MemConfig) *result.PartialResult {
+	partialKills := result.NewPartialResult()
+
+	if err != nil {
+		partialKills.SetState(check.OK)
+		partialKills.SetOutput(fmt.Sprintf("OOM kills (%s): %s", name, state.Explain(err)))
+
+		return partialKills
+	}
+
+	pdKills := check.Perfdata{
+		Label: label,
+		Value: kills,
+		Min:   0,
+	}
+
+	partialKills.SetState(config.OOMKills.Evaluate(float64(kills), &pdKills))
+
+	output := fmt.Sprintf("OOM kills (%s): %d since the last check", name, kills)
+
+	if partialKills.GetStatus() != check.OK {
+		output += " violates threshold"
+	}
+
+	if oomEvents != nil {
+		output += fmt.Sprintf(", limit reached without reclaimable memory %d times", *oomEvents)
+	}
+
+	partialKills.SetOutput(output)
+	partialKills.AddPerfdata(&pdKills)
+
+	return partialKills
+}
+
+func oomNotAvailable(name, reason string) *result.PartialResult {
+	partialKills := result.NewPartialResult()
+	partialKills.SetState(check.Unknown)
+	partialKills.SetOutput(fmt.Sprintf("OOM kills (%s): %s", name, reason))
+
+	return partialKills
+}
+
+// validateMeminfoOptions checks whether the thresholds only refer to fields of /proc/meminfo.
+// The fields depend on the kernel, therefore this is checked against the actual values
+func validateMeminfoOptions(config *memory.MemConfig, meminfo memory.Meminfo) error {
+	for _, name := range thresholds.Names(config.WarningMeminfo, config.CriticalMeminfo) {
+		if _, ok := meminfo[name]; !ok {
+			return fmt.Errorf("unknown meminfo field %q, available are: %s", name, strings.Join(meminfo.Names(), ", "))
+		}
+	}
+
+	return nil
+}
+
+// computeMeminfoResults reports the fields of the breakdown (if enabled) and the fields with thresholds.
+// It returns nil if there is nothing to report
+func computeMeminfoResults(config *memory.MemConfig, meminfo memory.Meminfo) *result.PartialResult {
+	names := make([]string, 0)
+
+	if config.MeminfoBreakdown {
+		for _, name := range memory.BreakdownFields {
+			// Older kernels or kernels without hugepages do not have all fields
+			if _, ok := meminfo[name]; ok {
+				names = append(names, name)
+			}
+		}
+	}
+
+	for _, name := range thresholds.Names(config.WarningMeminfo, config.CriticalMeminfo) {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	if len(names) == 0 {
+		return nil
+	}
+
+	partialMeminfo := result.NewPartialResult()
+	partialMeminfo.SetOutput("Memory breakdown")
+	partialMeminfo.SetDefaultState(check.OK)
+
+	for _, name := range names {
+		value := meminfo[name]
+
+		pd := check.Perfdata{
+			Label: meminfoLabel(name),
+			Value: value,
+			Min:   0,
+		}
+
+		output := fmt.Sprintf("%s: %d", name, value)
+
+		if memory.IsSize(name) {
+			pd.Uom = "B"
+			output = fmt.Sprintf("%s: %s", name, convert.BytesIEC(value))
+		}
+
+		ths := thresholds.Select(config.WarningMeminfo, config.CriticalMeminfo, name)
+
+		sc := result.NewPartialResult()
+		sc.SetState(ths.Evaluate(float64(value), &pd))
+
+		if sc.GetStatus() != check.OK {
+			output += " violates threshold"
+		}
+
+		sc.SetOutput(output)
+		sc.AddPerfdata(&pd)
+
+		partialMeminfo.AddSubcheck(sc)
+	}
+
+	return partialMeminfo
+}
+
+// meminfoLabel converts the name of a meminfo field to a perfdata label, e.g. Active(anon) to meminfo_active_anon
+func meminfoLabel(name string) string {
+	label := strings.ToLower(name)
+	label = strings.ReplaceAll(label, "(", "_")
+	label = strings.ReplaceAll(label, ")", "")
+
+	return "meminfo_" + label
 }
 
 func computeMemResults(config *memory.MemConfig, memStats *memory.Mem) *result.PartialResult {
@@ -399,14 +855,93 @@ func init() {
 				},
 			},
 		},
+		{
+			Th:          &MemoryConfig.SwapInRate.Warn,
+			FlagString:  "swap-in-rate-warning",
+			Description: "Warning threshold for the pages swapped in per second since the previous execution (implies --swap-activity)",
+		},
+		{
+			Th:          &MemoryConfig.SwapInRate.Crit,
+			FlagString:  "swap-in-rate-critical",
+			Description: "Critical threshold for the pages swapped in per second since the previous execution (implies --swap-activity)",
+		},
+		{
+			Th:          &MemoryConfig.SwapOutRate.Warn,
+			FlagString:  "swap-out-rate-warning",
+			Description: "Warning threshold for the pages swapped out per second since the previous execution (implies --swap-activity)",
+		},
+		{
+			Th:          &MemoryConfig.SwapOutRate.Crit,
+			FlagString:  "swap-out-rate-critical",
+			Description: "Critical threshold for the pages swapped out per second since the previous execution (implies --swap-activity)",
+		},
+		{
+			Th:          &MemoryConfig.MajorFaultRate.Warn,
+			FlagString:  "major-faults-rate-warning",
+			Description: "Warning threshold for the major page faults per second since the previous execution (implies --swap-activity)",
+		},
+		{
+			Th:          &MemoryConfig.MajorFaultRate.Crit,
+			FlagString:  "major-faults-rate-critical",
+			Description: "Critical threshold for the major page faults per second since the previous execution (implies --swap-activity)",
+		},
+		{
+			Th:          &MemoryConfig.OOMKills.Warn,
+			FlagString:  "oom-kills-warning",
+			Description: "Warning threshold for the number of OOM kills since the previous execution, of the system and of every cgroup",
+			Default: thresholds.ThresholdWrapper{
+				IsSet: true,
+				Th: check.Threshold{
+					Lower: 0,
+					Upper: 0,
+				},
+			},
+		},
+		{
+			Th:          &MemoryConfig.OOMKills.Crit,
+			FlagString:  "oom-kills-critical",
+			Description: "Critical threshold for the number of OOM kills since the previous execution, of the system and of every cgroup",
+		},
+		{
+			Th:          &MemoryConfig.CommittedPercentage.Warn,
+			FlagString:  "committed-memory-warning-percentage",
+			Description: "Warning threshold for the committed memory in percent of the CommitLimit (implies --committed-memory)",
+		},
+		{
+			Th:          &MemoryConfig.CommittedPercentage.Crit,
+			FlagString:  "committed-memory-critical-percentage",
+			Description: "Critical threshold for the committed memory in percent of the CommitLimit (implies --committed-memory)",
+		},
 	}
 
 	// Thresholds
 	thresholds.AddFlags(memPerFs, &memoryThresholds)
 
+	memPerFs.BoolVar(&MemoryConfig.SwapActivity, "swap-activity", false,
+		"Report the pages swapped in and out and the major page faults per second since the previous execution (from /proc/vmstat)")
+	memPerFs.BoolVar(&MemoryConfig.TrackOOMKills, "oom-kills", false,
+		"Report the OOM kills since the previous execution (from the oom_kill counter in /proc/vmstat)")
+	memPerFs.StringSliceVar(&MemoryConfig.OOMCgroups, "oom-cgroup", nil,
+		"Report the OOM kills since the previous execution of this cgroup v2 (may be repeated, implies --oom-kills). E.g. 'system.slice/mysql.service'")
+	memPerFs.BoolVar(&MemoryConfig.CommittedMemory, "committed-memory", false,
+		"Report the committed memory (Committed_AS) in relation to the CommitLimit and the overcommit mode")
+
+	memPerFs.StringVar(&MemoryConfig.Cgroup, "cgroup", "",
+		"Check the memory of this cgroup v2 against its limits instead of the host, the path is relative to /sys/fs/cgroup. E.g. 'system.slice/mysql.service'")
+	memPerFs.BoolVar(&MemoryConfig.NoCgroupDetection, "no-cgroup-detection", false,
+		"Check the host, even if running in a container with a memory limit")
+	memPerFs.BoolVar(&MemoryConfig.MeminfoBreakdown, "meminfo-breakdown", false,
+		"Report the fields of /proc/meminfo which explain the memory usage (Buffers, Cached, Shmem, Mapped, Dirty, Writeback, Slab, PageTables and Hugepages)")
+	memPerFs.Var(&MemoryConfig.WarningMeminfo, "meminfo-warning",
+		"Warning threshold for a field of /proc/meminfo in bytes (or pages for HugePages_*), selected by its name (may be repeated). E.g. 'Dirty=1073741824'")
+	memPerFs.Var(&MemoryConfig.CriticalMeminfo, "meminfo-critical",
+		"Critical threshold for a field of /proc/meminfo in bytes (or pages for HugePages_*), selected by its name (may be repeated). E.g. 'SUnreclaim=2147483648'")
+
 	memPerFs.BoolVarP(&MemoryConfig.PercentageInPerfdata, "percentage-in-perfdata", "", false, "Add computed percentage values to perfdata, although they are technically redundant")
 
 	memPerFs.SortFlags = false
+
+	registerCheck(memoryCmd, memoryCheck)
 }
 
 func computeSwapResults(stats *memory.Mem) *result.PartialResult {
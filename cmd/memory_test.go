@@ -3,6 +3,7 @@ package cmd
 import (
 	"testing"
 
+	"github.com/NETWAYS/check_system_basics/internal/common/state"
 	"github.com/NETWAYS/check_system_basics/internal/common/thresholds"
 	"github.com/NETWAYS/check_system_basics/internal/memory"
 
@@ -60,3 +61,196 @@ func TestComputeMemResultsWithThresholds(t *testing.T) {
 		t.Fatalf("expected %v, got %v", check.Warning, memPartial.GetStatus())
 	}
 }
+
+func TestComputeMeminfoResults(t *testing.T) {
+	meminfo := memory.Meminfo{
+		"Slab":            512 * 1024 * 1024,
+		"SUnreclaim":      384 * 1024 * 1024,
+		"Dirty":           8 * 1024 * 1024,
+		"HugePages_Total": 16,
+		"Active(anon)":    64 * 1024 * 1024,
+	}
+
+	config := memory.MemConfig{}
+
+	if computeMeminfoResults(&config, meminfo) != nil {
+		t.Fatalf("expected no result without breakdown and thresholds")
+	}
+
+	config.MeminfoBreakdown = true
+
+	err := config.CriticalMeminfo.Set("SUnreclaim=268435456")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	err = config.WarningMeminfo.Set("Active(anon)=1048576")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	err = validateMeminfoOptions(&config, meminfo)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	meminfoPartial := computeMeminfoResults(&config, meminfo)
+
+	if check.Critical != meminfoPartial.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.Critical, meminfoPartial.GetStatus())
+	}
+
+	err = config.WarningMeminfo.Set("Unknown=1")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	err = validateMeminfoOptions(&config, meminfo)
+	if err == nil {
+		t.Fatalf("expected an error, got none")
+	}
+
+	if meminfoLabel("Active(anon)") != "meminfo_active_anon" {
+		t.Fatalf("expected %v, got %v", "meminfo_active_anon", meminfoLabel("Active(anon)"))
+	}
+}
+
+func TestComputeCommittedResults(t *testing.T) {
+	overcommit := memory.Overcommit{
+		Mode:        memory.OvercommitNever,
+		Ratio:       50,
+		CommittedAS: 3 * 1024 * 1024 * 1024,
+		CommitLimit: 4 * 1024 * 1024 * 1024,
+		MemTotal:    8 * 1024 * 1024 * 1024,
+	}
+
+	config := memory.MemConfig{}
+
+	committedPartial := computeCommittedResults(&config, &overcommit)
+
+	expected := "[OK] Committed Memory 75.00% (3GiB / 4GiB), overcommit mode 2 (never): allocations beyond the CommitLimit fail, CommitLimit = (RAM 8GiB - hugepages 0B) * overcommit_ratio 50% + swap 0B"
+	if committedPartial.String() != expected {
+		t.Fatalf("expected %v, got %v", expected, committedPartial.String())
+	}
+
+	err := config.CommittedPercentage.Crit.Set("70")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	committedPartial = computeCommittedResults(&config, &overcommit)
+
+	if check.Critical != committedPartial.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.Critical, committedPartial.GetStatus())
+	}
+}
+
+func TestComputeOOMKills(t *testing.T) {
+	config := memory.MemConfig{}
+
+	err := config.OOMKills.Warn.Set("0")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	killsPartial := computeOOMKills("system", "oom_kills", 0, nil, nil, &config)
+
+	if killsPartial.String() != "[OK] OOM kills (system): 0 since the last check" {
+		t.Fatalf("expected %v, got %v", "[OK] OOM kills (system): 0 since the last check", killsPartial.String())
+	}
+
+	oomEvents := uint64(2)
+
+	killsPartial = computeOOMKills("system.slice/mysql.service", "oom_kills_system.slice/mysql.service", 1, &oomEvents, nil, &config)
+
+	expected := "[WARNING] OOM kills (system.slice/mysql.service): 1 since the last check violates threshold, limit reached without reclaimable memory 2 times"
+	if killsPartial.String() != expected {
+		t.Fatalf("expected %v, got %v", expected, killsPartial.String())
+	}
+
+	// Without a previous execution nothing is known about the kills
+	killsPartial = computeOOMKills("system", "oom_kills", 0, nil, state.ErrNoPreviousValue, &config)
+
+	if check.OK != killsPartial.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.OK, killsPartial.GetStatus())
+	}
+}
+
+func TestComputeVmstatRate(t *testing.T) {
+	ths := thresholds.Thresholds{}
+
+	err := ths.Crit.Set("100")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	ratePartial := computeVmstatRate("Pages swapped out", "pswpout", 12.5, nil, &ths)
+
+	if ratePartial.String() != "[OK] Pages swapped out: 12.50/s" {
+		t.Fatalf("expected %v, got %v", "[OK] Pages swapped out: 12.50/s", ratePartial.String())
+	}
+
+	ratePartial = computeVmstatRate("Pages swapped out", "pswpout", 250, nil, &ths)
+
+	if check.Critical != ratePartial.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.Critical, ratePartial.GetStatus())
+	}
+
+	ratePartial = computeVmstatRate("Pages swapped out", "pswpout", 0, state.ErrCounterReset, &ths)
+
+	if check.OK != ratePartial.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.OK, ratePartial.GetStatus())
+	}
+}
+
+func TestComputeCgroupResults(t *testing.T) {
+	limit := uint64(2 * 1024 * 1024 * 1024)
+	high := uint64(1024 * 1024 * 1024)
+	swapMax := uint64(0)
+
+	cgroup := memory.Cgroup{
+		Path:           "kubepods/pod1",
+		Current:        1536 * 1024 * 1024,
+		Max:            &limit,
+		Anon:           1024 * 1024 * 1024,
+		File:           512 * 1024 * 1024,
+		InactiveFile:   256 * 1024 * 1024,
+		SwapAccounting: true,
+		SwapMax:        &swapMax,
+	}
+
+	cgroupPartial := computeCgroupResults(&cgroup)
+
+	expected := "[OK] cgroup kubepods/pod1: memory.current 1536MiB of memory.max 2GiB, anon 1024MiB, file 512MiB, swap 0B of swap.max 0B"
+	if cgroupPartial.String() != expected {
+		t.Fatalf("expected %v, got %v", expected, cgroupPartial.String())
+	}
+
+	cgroup.High = &high
+
+	cgroupPartial = computeCgroupResults(&cgroup)
+
+	if check.Warning != cgroupPartial.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.Warning, cgroupPartial.GetStatus())
+	}
+
+	// The thresholds of the host are evaluated against the limit of the cgroup
+	config := memory.MemConfig{}
+
+	err := config.MemAvailablePercentage.Crit.Set("50:")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	host := memory.Mem{
+		VirtMem:  &mem.VirtualMemoryStat{Total: 16 * 1024 * 1024 * 1024},
+		SwapInfo: &mem.SwapMemoryStat{},
+	}
+
+	// 768MiB of 2GiB are available
+	memPartial := computeMemResults(&config, cgroup.Mem(&host))
+
+	if check.Critical != memPartial.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.Critical, memPartial.GetStatus())
+	}
+}
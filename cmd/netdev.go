@@ -1,6 +1,13 @@
 package cmd
 
 import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/NETWAYS/check_system_basics/internal/common/state"
+	"github.com/NETWAYS/check_system_basics/internal/common/thresholds"
 	"github.com/NETWAYS/check_system_basics/internal/netdev"
 	"github.com/NETWAYS/go-check"
 	"github.com/NETWAYS/go-check/result"
@@ -12,16 +19,18 @@ var NetdevConfig netdev.CheckConfig
 var netdevCmd = &cobra.Command{
 	Use:   "netdev",
 	Short: "Submodule to detect, display and check against the interfaces on the local machine",
-	Example: `./check_system_basics netdev
-[WARNING] - states: warning=4 ok=2
-\_ [WARNING] virbr0 is Down
-\_ [WARNING] lo is Unknown
-\_ [OK] enx00e04c6801bd is Up
-\_ [OK] wlp170s0 is Up
-\_ [WARNING] tun0 is Unknown
-\_ [WARNING] docker0 is Down
-|virbr0_rx_bytes=0 virbr0_rx_errors=0 virbr0_rx_dropped=0 virbr0_rx_packets=0 virbr0_tx_bytes=0 virbr0_tx_errors=0 virbr0_tx_dropped=0 virbr0_tx_packets=0 lo_rx_bytes=5991167 lo_rx_errors=0 lo_rx_dropped=0 lo_rx_packets=11264 lo_tx_bytes=5991167 lo_tx_errors=0 lo_tx_dropped=0 lo_tx_packets=11264 enx00e04c6801bd_rx_bytes=259382300 enx00e04c6801bd_rx_errors=0 enx00e04c6801bd_rx_dropped=21297 enx00e04c6801bd_rx_packets=711180 enx00e04c6801bd_tx_bytes=181029368 enx00e04c6801bd_tx_errors=0 enx00e04c6801bd_tx_dropped=0 enx00e04c6801bd_tx_packets=653583 wlp170s0_rx_bytes=4841533 wlp170s0_rx_errors=0 wlp170s0_rx_dropped=21110 wlp170s0_rx_packets=46034 wlp170s0_tx_bytes=160490 wlp170s0_tx_errors=0 wlp170s0_tx_dropped=0 wlp170s0_tx_packets=1453 tun0_rx_bytes=204101734 tun0_rx_errors=0 tun0_rx_dropped=0 tun0_rx_packets=349065 tun0_tx_bytes=121967676 tun0_tx_errors=0 tun0_tx_dropped=0 tun0_tx_packets=377347 docker0_rx_bytes=0 docker0_rx_errors=0 docker0_rx_dropped=0 docker0_rx_packets=0 docker0_tx_bytes=0 docker0_tx_errors=0 docker0_tx_dropped=0 docker0_tx_packets=0`,
-	Run: NetdevCheck,
+	Example: `./check_system_basics netdev --exclude-interface-type virtual
+[WARNING] - wlp2s0 is Down
+\_ [OK] enp3s0 is Up
+\_ [WARNING] wlp2s0 is Down
+|enp3s0_rx_bytes=259382300 enp3s0_rx_errors=0 enp3s0_rx_dropped=21297 enp3s0_rx_packets=711180 enp3s0_rx_fifo_errors=0 enp3s0_rx_frame_errors=0 enp3s0_rx_compressed=0 enp3s0_multicast=1520 enp3s0_tx_bytes=181029368 enp3s0_tx_errors=0 enp3s0_tx_dropped=0 enp3s0_tx_packets=653583 enp3s0_tx_fifo_errors=0 enp3s0_tx_compressed=0 wlp2s0_rx_bytes=0 wlp2s0_rx_errors=0 wlp2s0_rx_dropped=0 wlp2s0_rx_packets=0 wlp2s0_rx_fifo_errors=0 wlp2s0_rx_frame_errors=0 wlp2s0_rx_compressed=0 wlp2s0_multicast=0 wlp2s0_tx_bytes=0 wlp2s0_tx_errors=0 wlp2s0_tx_dropped=0 wlp2s0_tx_packets=0 wlp2s0_tx_fifo_errors=0 wlp2s0_tx_compressed=0
+
+./check_system_basics netdev --include-interface-name '^enp' --expect-min-speed '^enp=10000'
+[WARNING] - Link: 1000 Mbit/s, full duplex, MTU 1500, carrier up, 2 carrier changes (expected at least 10000 Mbit/s)
+\_ [WARNING] enp3s0 is Up
+    \_ [WARNING] Link: 1000 Mbit/s, full duplex, MTU 1500, carrier up, 2 carrier changes (expected at least 10000 Mbit/s)
+|enp3s0_rx_bytes=259382300 enp3s0_rx_errors=0 enp3s0_rx_dropped=21297 enp3s0_rx_packets=711180 enp3s0_rx_fifo_errors=0 enp3s0_rx_frame_errors=0 enp3s0_rx_compressed=0 enp3s0_multicast=1520 enp3s0_tx_bytes=181029368 enp3s0_tx_errors=0 enp3s0_tx_dropped=0 enp3s0_tx_packets=653583 enp3s0_tx_fifo_errors=0 enp3s0_tx_compressed=0 enp3s0_carrier_changes=2c;;;0 enp3s0_speed=1000;;;0 enp3s0_mtu=1500;;;0`,
+	Run: runCheck(netdevCheck),
 }
 
 func init() {
@@ -35,25 +44,203 @@ func init() {
 		"exclude-interface-name",
 		[]string{"^lo$"},
 		"Ignore all interfaces where the interface name matches this regexp regex (may be repeated). E.g. 'eth', '^et.*'")
+	fs.StringSliceVar(&NetdevConfig.Filters.IncludeInterfaceTypes, "include-interface-type", nil,
+		"Explicitly include only interfaces of this type (may be repeated). Available types: "+strings.Join(netdev.GetIfaceKinds(), ", "))
+	fs.StringSliceVar(&NetdevConfig.Filters.ExcludeInterfaceTypes, "exclude-interface-type", nil,
+		"Ignore all interfaces of this type (may be repeated). Available types: "+strings.Join(netdev.GetIfaceKinds(), ", "))
+	fs.IPNetSliceVar(&NetdevConfig.Filters.IncludeIPRange, "include-ip-range", nil,
+		"Explicitly include only interfaces with an address in this network in CIDR notation (may be repeated). E.g. '10.20.0.0/16', '2001:db8::/32'")
+	fs.IPNetSliceVar(&NetdevConfig.Filters.ExcludeIPRange, "exclude-ip-range", nil,
+		"Ignore all interfaces with an address in this network in CIDR notation (may be repeated). E.g. '169.254.0.0/16', 'fe80::/10'")
 	fs.BoolVar(&NetdevConfig.DownIsCritical, "down-is-critical", false,
 		"Setting this option will set the state to CRITICAL if an interface is DOWN")
 	fs.BoolVar(&NetdevConfig.NotUpIsOK, "not-up-is-ok", false,
 		"Setting this option will set the state to OK regardless of the actual state of an interface")
 	fs.BoolVar(&NetdevConfig.UnknownIsOk, "unknown-is-ok", false,
 		"Setting this option will set the state to OK if the interface is in a state of UNKNOWN")
+
+	netdevThresholds := []thresholds.ThresholdOption{
+		{
+			Th:          &NetdevConfig.WarningTotalCountOfInterfaces,
+			FlagString:  "warning-total-count-of-interfaces",
+			Description: "A warning threshold for the number of interfaces matching the filters which are up",
+		},
+		{
+			Th:          &NetdevConfig.CriticalTotalCountOfInterfaces,
+			FlagString:  "critical-total-count-of-interfaces",
+			Description: "A critical threshold for the number of interfaces matching the filters which are up",
+		},
+		{
+			Th:          &NetdevConfig.RxBitsPerSecond.Warn,
+			FlagString:  "warning-rx-bps",
+			Description: "Warning threshold for the received bits per second (per interface)",
+		},
+		{
+			Th:          &NetdevConfig.RxBitsPerSecond.Crit,
+			FlagString:  "critical-rx-bps",
+			Description: "Critical threshold for the received bits per second (per interface)",
+		},
+		{
+			Th:          &NetdevConfig.TxBitsPerSecond.Warn,
+			FlagString:  "warning-tx-bps",
+			Description: "Warning threshold for the transmitted bits per second (per interface)",
+		},
+		{
+			Th:          &NetdevConfig.TxBitsPerSecond.Crit,
+			FlagString:  "critical-tx-bps",
+			Description: "Critical threshold for the transmitted bits per second (per interface)",
+		},
+		{
+			Th:          &NetdevConfig.RxPacketsPerSecond.Warn,
+			FlagString:  "warning-rx-pps",
+			Description: "Warning threshold for the received packets per second (per interface)",
+		},
+		{
+			Th:          &NetdevConfig.RxPacketsPerSecond.Crit,
+			FlagString:  "critical-rx-pps",
+			Description: "Critical threshold for the received packets per second (per interface)",
+		},
+		{
+			Th:          &NetdevConfig.TxPacketsPerSecond.Warn,
+			FlagString:  "warning-tx-pps",
+			Description: "Warning threshold for the transmitted packets per second (per interface)",
+		},
+		{
+			Th:          &NetdevConfig.TxPacketsPerSecond.Crit,
+			FlagString:  "critical-tx-pps",
+			Description: "Critical threshold for the transmitted packets per second (per interface)",
+		},
+		{
+			Th:          &NetdevConfig.RxUtilization.Warn,
+			FlagString:  "warning-rx-utilization",
+			Description: "Warning threshold for the received traffic in percent of the link speed (per interface)",
+		},
+		{
+			Th:          &NetdevConfig.RxUtilization.Crit,
+			FlagString:  "critical-rx-utilization",
+			Description: "Critical threshold for the received traffic in percent of the link speed (per interface)",
+		},
+		{
+			Th:          &NetdevConfig.TxUtilization.Warn,
+			FlagString:  "warning-tx-utilization",
+			Description: "Warning threshold for the transmitted traffic in percent of the link speed (per interface)",
+		},
+		{
+			Th:          &NetdevConfig.TxUtilization.Crit,
+			FlagString:  "critical-tx-utilization",
+			Description: "Critical threshold for the transmitted traffic in percent of the link speed (per interface)",
+		},
+		{
+			Th:          &NetdevConfig.ErrorsPerSecond.Warn,
+			FlagString:  "warning-errors-rate",
+			Description: "Warning threshold for the errors per second (per interface and direction)",
+		},
+		{
+			Th:          &NetdevConfig.ErrorsPerSecond.Crit,
+			FlagString:  "critical-errors-rate",
+			Description: "Critical threshold for the errors per second (per interface and direction)",
+		},
+		{
+			Th:          &NetdevConfig.DroppedPerSecond.Warn,
+			FlagString:  "warning-dropped-rate",
+			Description: "Warning threshold for the dropped packets per second (per interface and direction)",
+		},
+		{
+			Th:          &NetdevConfig.DroppedPerSecond.Crit,
+			FlagString:  "critical-dropped-rate",
+			Description: "Critical threshold for the dropped packets per second (per interface and direction)",
+		},
+		{
+			Th:          &NetdevConfig.ErrorRatio.Warn,
+			FlagString:  "warning-errors-ratio",
+			Description: "Warning threshold for the errors in percent of all packets (per interface and direction)",
+		},
+		{
+			Th:          &NetdevConfig.ErrorRatio.Crit,
+			FlagString:  "critical-errors-ratio",
+			Description: "Critical threshold for the errors in percent of all packets (per interface and direction)",
+		},
+		{
+			Th:          &NetdevConfig.DropRatio.Warn,
+			FlagString:  "warning-dropped-ratio",
+			Description: "Warning threshold for the dropped packets in percent of all packets (per interface and direction)",
+		},
+		{
+			Th:          &NetdevConfig.DropRatio.Crit,
+			FlagString:  "critical-dropped-ratio",
+			Description: "Critical threshold for the dropped packets in percent of all packets (per interface and direction)",
+		},
+		{
+			Th:          &NetdevConfig.CarrierChanges.Warn,
+			FlagString:  "warning-carrier-changes",
+			Description: "Warning threshold for the carrier changes since the previous execution (per interface)",
+		},
+		{
+			Th:          &NetdevConfig.CarrierChanges.Crit,
+			FlagString:  "critical-carrier-changes",
+			Description: "Critical threshold for the carrier changes since the previous execution (per interface)",
+		},
+	}
+
+	thresholds.AddFlags(fs, &netdevThresholds)
+
+	fs.Var(&NetdevConfig.MinSpeed, "expect-min-speed",
+		"Expected minimal link speed in Mbit/s for interfaces whose names match the regexp (may be repeated, the last match wins). E.g. '^ens=10000'")
+	fs.StringSliceVar(&NetdevConfig.FullDuplex, "expect-full-duplex", nil,
+		"Expect full duplex for interfaces whose names match this regexp (may be repeated). E.g. '^ens'")
+	fs.Var(&NetdevConfig.MTU, "expect-mtu",
+		"Expected MTU for interfaces whose names match the regexp (may be repeated, the last match wins). E.g. '^ens=9000'")
+	fs.BoolVar(&NetdevConfig.LinkMismatchIsCritical, "link-mismatch-is-critical", false,
+		"Setting this option will set the state to CRITICAL if the link properties do not match the expectations")
+
+	fs.Var(&NetdevConfig.WarningStatisticRate, "warning-statistic-rate",
+		"Warning threshold for the rate per second of any interface statistic (may be repeated). E.g. 'rx_fifo_errors=10'")
+	fs.Var(&NetdevConfig.CriticalStatisticRate, "critical-statistic-rate",
+		"Critical threshold for the rate per second of any interface statistic (may be repeated). E.g. 'multicast=1000'")
+
+	fs.SortFlags = false
+
+	registerCheck(netdevCmd, netdevCheck)
 }
 
-func NetdevCheck(_ *cobra.Command, _ []string) {
-	overall := result.Overall{}
+func netdevCheck(cmd *cobra.Command) ([]*result.PartialResult, error) {
+	results := make([]*result.PartialResult, 0)
+
+	err := validateNetdevOptions(&NetdevConfig)
+	if err != nil {
+		return nil, err
+	}
 
-	interfaces, err := netdev.GetAllInterfaces()
+	interfaces, err := netdev.GetAllInterfaces(NetdevConfig.Filters.FiltersByAddress())
 	if err != nil {
-		check.ExitError(err)
+		return nil, err
 	}
 
 	interfaces, err = netdev.FilterInterfaces(&interfaces, &NetdevConfig.Filters)
 	if err != nil {
-		check.ExitError(err)
+		return nil, err
+	}
+
+	if NetdevConfig.CriticalTotalCountOfInterfaces.IsSet || NetdevConfig.WarningTotalCountOfInterfaces.IsSet {
+		results = append(results, computeNetdevCount(interfaces, &NetdevConfig))
+	}
+
+	var (
+		store    *state.Store
+		storeErr error
+	)
+
+	// The state is only needed for the thresholds on the rates and the carrier changes. If it can not be opened,
+	// the interfaces are still checked and only the rates are UNKNOWN
+	if NetdevConfig.StateEnabled() {
+		store, storeErr = openStateStore(cmd, "netdev")
+
+		if storeErr == nil && store.Previous == nil {
+			noRates := result.NewPartialResult()
+			noRates.SetState(check.OK)
+			noRates.SetOutput("No data from a previous execution, rates will be available with the next execution")
+			results = append(results, noRates)
+		}
 	}
 
 	for i := range interfaces {
@@ -62,24 +249,26 @@ func NetdevCheck(_ *cobra.Command, _ []string) {
 
 		sc.SetOutput(interfaces[i].Name + " is " + netdev.TranslateIfaceState(interfaces[i].Operstate))
 
+		ifaceState := check.OK
+
 		if !NetdevConfig.NotUpIsOK {
 			switch interfaces[i].Operstate {
 			case netdev.Up:
-				sc.SetState(check.OK)
+				ifaceState = check.OK
 			case netdev.Down:
 				if NetdevConfig.DownIsCritical {
-					sc.SetState(check.Critical)
+					ifaceState = check.Critical
 				} else {
-					sc.SetState(check.Warning)
+					ifaceState = check.Warning
 				}
 			case netdev.Unknown:
 				if NetdevConfig.UnknownIsOk {
-					sc.SetState(check.OK)
+					ifaceState = check.OK
 				} else {
-					sc.SetState(check.Warning)
+					ifaceState = check.Warning
 				}
 			default:
-				sc.SetState(check.Warning)
+				ifaceState = check.Warning
 			}
 		}
 
@@ -91,8 +280,438 @@ func NetdevCheck(_ *cobra.Command, _ []string) {
 			sc.AddPerfdata(&pd)
 		}
 
-		overall.AddSubcheck(sc)
+		if NetdevConfig.LinkEnabled() {
+			var carrierChanges *uint64
+
+			if store != nil {
+				if delta, err := store.Delta(interfaces[i].Name+"_carrier_changes", interfaces[i].CarrierChanges); err == nil {
+					carrierChanges = &delta
+				}
+			}
+
+			link := computeNetdevLink(&interfaces[i], carrierChanges, &NetdevConfig)
+			sc.AddSubcheck(link)
+
+			ifaceState = check.WorstState(ifaceState, link.GetStatus())
+		}
+
+		if storeErr != nil {
+			noRates := result.NewPartialResult()
+			noRates.SetState(check.Unknown)
+			noRates.SetOutput("Rates not available: " + storeErr.Error())
+			sc.AddSubcheck(noRates)
+
+			ifaceState = check.WorstState(ifaceState, check.Unknown)
+		}
+
+		if store != nil {
+			rates, err := netdev.GetInterfaceRates(store, &interfaces[i])
+
+			switch {
+			case err == nil:
+				rx := computeNetdevDirection(&interfaces[i], &rates, netdevRx, &NetdevConfig)
+				tx := computeNetdevDirection(&interfaces[i], &rates, netdevTx, &NetdevConfig)
+
+				sc.AddSubcheck(rx)
+				sc.AddSubcheck(tx)
+
+				ifaceState = check.WorstState(ifaceState, rx.GetStatus(), tx.GetStatus())
+
+				for _, statistic := range computeNetdevStatisticRates(&interfaces[i], &rates, &NetdevConfig) {
+					sc.AddSubcheck(statistic)
+
+					ifaceState = check.WorstState(ifaceState, statistic.GetStatus())
+				}
+			case store.Previous != nil:
+				// Without any previous data this was already reported once for all interfaces
+				noRates := result.NewPartialResult()
+				noRates.SetState(check.OK)
+				noRates.SetOutput("Rates not available: " + state.Explain(err))
+				sc.AddSubcheck(noRates)
+			}
+		}
+
+		sc.SetState(ifaceState)
+
+		results = append(results, sc)
+	}
+
+	if store != nil {
+		err = store.Close()
+		if err != nil {
+			results = append(results, stateNotSaved(err))
+		}
+	}
+
+	return results, nil
+}
+
+// computeNetdevCount evaluates the number of interfaces remaining after applying the filters
+func computeNetdevCount(interfaces []netdev.IfaceData, config *netdev.CheckConfig) *result.PartialResult {
+	countResult := result.NewPartialResult()
+	countResult.SetDefaultState(check.OK)
+
+	upCount := 0
+
+	for i := range interfaces {
+		if interfaces[i].Operstate == netdev.Up {
+			upCount++
+		}
+	}
+
+	tmpOutput := ""
+	if len(interfaces) == 1 {
+		tmpOutput = fmt.Sprintf("Found one matching interface (%d up)", upCount)
+	} else {
+		tmpOutput = fmt.Sprintf("Found %d matching interfaces (%d up)", len(interfaces), upCount)
+	}
+
+	if config.CriticalTotalCountOfInterfaces.IsSet && config.CriticalTotalCountOfInterfaces.Th.DoesViolate(float64(upCount)) {
+		countResult.SetState(check.Critical)
+
+		tmpOutput += ". This violates the expected number of " + config.CriticalTotalCountOfInterfaces.String() + " interfaces up"
+	} else if config.WarningTotalCountOfInterfaces.IsSet && config.WarningTotalCountOfInterfaces.Th.DoesViolate(float64(upCount)) {
+		countResult.SetState(check.Warning)
+
+		tmpOutput += ". This violates the expected number of " + config.WarningTotalCountOfInterfaces.String() + " interfaces up"
+	} else {
+		tmpOutput += ". This number resides within the given thresholds"
+	}
+
+	matches, err := netdev.MatchInterfaces(interfaces, config.Filters.IncludeInterfaceNames)
+	if err == nil && len(matches) > 0 {
+		patterns := make([]string, 0, len(matches))
+
+		for _, match := range matches {
+			switch {
+			case match.Found == 0:
+				patterns = append(patterns, match.Pattern+": no interface found")
+			case len(match.NotUp) > 0:
+				patterns = append(patterns, fmt.Sprintf("%s: %d found, %d up (not up: %s)",
+					match.Pattern, match.Found, match.Up, strings.Join(match.NotUp, ", ")))
+			default:
+				patterns = append(patterns, fmt.Sprintf("%s: %d found, %d up", match.Pattern, match.Found, match.Up))
+			}
+		}
+
+		tmpOutput += ". " + strings.Join(patterns, "; ")
+	}
+
+	countResult.SetOutput(tmpOutput)
+
+	pd := check.Perfdata{
+		Label: "interfaces",
+		Value: upCount,
+		Min:   0,
+	}
+
+	if config.WarningTotalCountOfInterfaces.IsSet {
+		pd.Warn = &config.WarningTotalCountOfInterfaces.Th
+	}
+
+	if config.CriticalTotalCountOfInterfaces.IsSet {
+		pd.Crit = &config.CriticalTotalCountOfInterfaces.Th
+	}
+
+	countResult.AddPerfdata(&pd)
+	countResult.AddPerfdata(&check.Perfdata{
+		Label: "interfaces_found",
+		Value: len(interfaces),
+		Min:   0,
+	})
+
+	return countResult
+}
+
+const (
+	netdevRx = "rx"
+	netdevTx = "tx"
+)
+
+// computeNetdevDirection evaluates the throughput, errors and drops of an interface in one direction
+func computeNetdevDirection(iface *netdev.IfaceData, rates *netdev.IfaceRates, direction string, config *netdev.CheckConfig) *result.PartialResult {
+	returnResult := result.NewPartialResult()
+	returnResult.SetDefaultState(check.OK)
+
+	var (
+		bitsPerSecond, packetsPerSecond, errorsPerSecond, droppedPerSecond float64
+		bpsThresholds, ppsThresholds, utilizationThresholds                *thresholds.Thresholds
+	)
+
+	if direction == netdevRx {
+		bitsPerSecond = rates.RxBitsPerSecond()
+		packetsPerSecond = rates.RxPacketsPerSecond()
+		errorsPerSecond = rates.RxErrorsPerSecond()
+		droppedPerSecond = rates.RxDroppedPerSecond()
+		bpsThresholds = &config.RxBitsPerSecond
+		ppsThresholds = &config.RxPacketsPerSecond
+		utilizationThresholds = &config.RxUtilization
+	} else {
+		bitsPerSecond = rates.TxBitsPerSecond()
+		packetsPerSecond = rates.TxPacketsPerSecond()
+		errorsPerSecond = rates.TxErrorsPerSecond()
+		droppedPerSecond = rates.TxDroppedPerSecond()
+		bpsThresholds = &config.TxBitsPerSecond
+		ppsThresholds = &config.TxPacketsPerSecond
+		utilizationThresholds = &config.TxUtilization
+	}
+
+	prefix := iface.Name + "_" + direction
+
+	pdBps := check.Perfdata{
+		Label: prefix + "_bps",
+		Value: bitsPerSecond,
+		Min:   0,
+	}
+
+	pdPps := check.Perfdata{
+		Label: prefix + "_pps",
+		Value: packetsPerSecond,
+		Min:   0,
+	}
+
+	errorRatio := netdev.Ratio(errorsPerSecond, packetsPerSecond)
+	dropRatio := netdev.Ratio(droppedPerSecond, packetsPerSecond)
+
+	pdErrors := check.Perfdata{
+		Label: prefix + "_errors_rate",
+		Value: errorsPerSecond,
+		Min:   0,
+	}
+
+	pdDropped := check.Perfdata{
+		Label: prefix + "_dropped_rate",
+		Value: droppedPerSecond,
+		Min:   0,
+	}
+
+	pdErrorRatio := check.Perfdata{
+		Label: prefix + "_errors_ratio",
+		Value: errorRatio,
+		Uom:   "%",
+		Min:   0,
+		Max:   100,
+	}
+
+	pdDropRatio := check.Perfdata{
+		Label: prefix + "_dropped_ratio",
+		Value: dropRatio,
+		Uom:   "%",
+		Min:   0,
+		Max:   100,
+	}
+
+	states := []check.Status{
+		bpsThresholds.Evaluate(bitsPerSecond, &pdBps),
+		ppsThresholds.Evaluate(packetsPerSecond, &pdPps),
+		config.ErrorsPerSecond.Evaluate(errorsPerSecond, &pdErrors),
+		config.DroppedPerSecond.Evaluate(droppedPerSecond, &pdDropped),
+		config.ErrorRatio.Evaluate(errorRatio, &pdErrorRatio),
+		config.DropRatio.Evaluate(dropRatio, &pdDropRatio),
+	}
+
+	output := strings.Builder{}
+	output.WriteString(fmt.Sprintf("%s: %s", strings.ToUpper(direction), netdev.FormatBitRate(bitsPerSecond)))
+
+	var pdUtilization *check.Perfdata
+
+	utilization, speedKnown := iface.Utilization(bitsPerSecond)
+	if speedKnown {
+		pdBps.Max = iface.Speed * 1000 * 1000
+
+		pdUtilization = &check.Perfdata{
+			Label: prefix + "_utilization",
+			Value: utilization,
+			Uom:   "%",
+			Min:   0,
+			Max:   100,
+		}
+
+		states = append(states, utilizationThresholds.Evaluate(utilization, pdUtilization))
+
+		output.WriteString(fmt.Sprintf(" (%.2f%% of %d Mbit/s)", utilization, iface.Speed))
+	} else if utilizationThresholds.IsSet() {
+		output.WriteString(" (link speed unknown)")
+	}
+
+	output.WriteString(fmt.Sprintf(", %.2f packets/s, %.2f errors/s (%.2f%%), %.2f dropped/s (%.2f%%)",
+		packetsPerSecond, errorsPerSecond, errorRatio, droppedPerSecond, dropRatio))
+
+	returnResult.SetState(check.WorstState(states...))
+
+	if returnResult.GetStatus() != check.OK {
+		output.WriteString(" violates threshold")
+	}
+
+	returnResult.SetOutput(output.String())
+	returnResult.AddPerfdata(&pdBps)
+	returnResult.AddPerfdata(&pdPps)
+
+	if pdUtilization != nil {
+		returnResult.AddPerfdata(pdUtilization)
+	}
+
+	returnResult.AddPerfdata(&pdErrors)
+	returnResult.AddPerfdata(&pdErrorRatio)
+	returnResult.AddPerfdata(&pdDropped)
+	returnResult.AddPerfdata(&pdDropRatio)
+
+	return returnResult
+}
+
+// computeNetdevStatisticRates evaluates the thresholds on the rates of explicitly selected statistics
+func computeNetdevStatisticRates(iface *netdev.IfaceData, rates *netdev.IfaceRates, config *netdev.CheckConfig) []*result.PartialResult {
+	names := thresholds.Names(config.WarningStatisticRate, config.CriticalStatisticRate)
+	results := make([]*result.PartialResult, 0, len(names))
+
+	for _, name := range names {
+		rate, _ := rates.Get(name)
+
+		pd := check.Perfdata{
+			Label: iface.Name + "_" + name + "_per_second",
+			Value: rate,
+			Min:   0,
+		}
+
+		ths := thresholds.Select(config.WarningStatisticRate, config.CriticalStatisticRate, name)
+
+		sc := result.NewPartialResult()
+		sc.SetState(ths.Evaluate(rate, &pd))
+
+		if sc.GetStatus() == check.OK {
+			sc.SetOutput(fmt.Sprintf("%s: %.2f/s", name, rate))
+		} else {
+			sc.SetOutput(fmt.Sprintf("%s: %.2f/s violates threshold", name, rate))
+		}
+
+		sc.AddPerfdata(&pd)
+		results = append(results, sc)
+	}
+
+	return results
+}
+
+// computeNetdevLink evaluates the link properties of an interface against the expected values.
+// carrierChanges is nil if the number of carrier changes since the previous execution is not known
+func computeNetdevLink(iface *netdev.IfaceData, carrierChanges *uint64, config *netdev.CheckConfig) *result.PartialResult {
+	linkResult := result.NewPartialResult()
+	linkResult.SetDefaultState(check.OK)
+
+	mismatchState := check.Warning
+	if config.LinkMismatchIsCritical {
+		mismatchState = check.Critical
+	}
+
+	linkState := check.OK
+	mismatches := make([]string, 0)
+
+	speed := "speed unknown"
+	if iface.Speed != netdev.SpeedUnknown {
+		speed = fmt.Sprintf("%d Mbit/s", iface.Speed)
+	}
+
+	if minSpeed, ok := config.MinSpeed.Lookup(iface.Name); ok && (iface.Speed == netdev.SpeedUnknown || iface.Speed < minSpeed) {
+		mismatches = append(mismatches, fmt.Sprintf("expected at least %d Mbit/s", minSpeed))
+	}
+
+	for _, pattern := range config.FullDuplex {
+		// The patterns are validated beforehand
+		if match, _ := regexp.MatchString(pattern, iface.Name); match && iface.Duplex != netdev.DuplexFull {
+			mismatches = append(mismatches, "expected full duplex")
+			break
+		}
+	}
+
+	if mtu, ok := config.MTU.Lookup(iface.Name); ok && iface.MTU != mtu {
+		mismatches = append(mismatches, fmt.Sprintf("expected MTU %d", mtu))
+	}
+
+	if len(mismatches) > 0 {
+		linkState = mismatchState
+	}
+
+	duplex := iface.Duplex + " duplex"
+	if iface.Duplex == netdev.DuplexUnknown {
+		duplex = "duplex unknown"
+	}
+
+	carrier := "down"
+	if iface.Carrier {
+		carrier = "up"
+	}
+
+	output := fmt.Sprintf("Link: %s, %s, MTU %d, carrier %s, %d carrier changes",
+		speed, duplex, iface.MTU, carrier, iface.CarrierChanges)
+
+	pdCarrierChanges := check.Perfdata{
+		Label: iface.Name + "_carrier_changes",
+		Value: iface.CarrierChanges,
+		Uom:   "c",
+		Min:   0,
+	}
+
+	if carrierChanges != nil {
+		output += fmt.Sprintf(" (%d since the previous execution)", *carrierChanges)
+
+		// The thresholds apply to the difference, therefore they are not part of the perfdata of the counter
+		changesState := config.CarrierChanges.Evaluate(float64(*carrierChanges), nil)
+		if changesState != check.OK {
+			output += " violates threshold"
+		}
+
+		linkState = check.WorstState(linkState, changesState)
+	}
+
+	if len(mismatches) > 0 {
+		output += " (" + strings.Join(mismatches, ", ") + ")"
+	}
+
+	linkResult.AddPerfdata(&pdCarrierChanges)
+
+	if iface.Speed != netdev.SpeedUnknown {
+		linkResult.AddPerfdata(&check.Perfdata{
+			Label: iface.Name + "_speed",
+			Value: iface.Speed,
+			Min:   0,
+		})
+	}
+
+	linkResult.AddPerfdata(&check.Perfdata{
+		Label: iface.Name + "_mtu",
+		Value: iface.MTU,
+		Min:   0,
+	})
+
+	linkResult.SetState(linkState)
+	linkResult.SetOutput(output)
+
+	return linkResult
+}
+
+// validateNetdevOptions checks whether the thresholds only refer to existing statistics
+func validateNetdevOptions(config *netdev.CheckConfig) error {
+	knownStatistics := netdev.GetIfaceStatNames()
+
+	for _, name := range thresholds.Names(config.WarningStatisticRate, config.CriticalStatisticRate) {
+		if !slices.Contains(knownStatistics, name) {
+			return fmt.Errorf("unknown interface statistic %q, available are: %s", name, strings.Join(knownStatistics, ", "))
+		}
+	}
+
+	for _, pattern := range config.FullDuplex {
+		_, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid interface name pattern %q: %w", pattern, err)
+		}
+	}
+
+	knownKinds := netdev.GetIfaceKinds()
+
+	for _, kind := range slices.Concat(config.Filters.IncludeInterfaceTypes, config.Filters.ExcludeInterfaceTypes) {
+		if !slices.Contains(knownKinds, kind) {
+			return fmt.Errorf("unknown interface type %q, available are: %s", kind, strings.Join(knownKinds, ", "))
+		}
 	}
 
-	check.Exit(overall.GetStatus(), overall.GetOutput())
+	return nil
 }
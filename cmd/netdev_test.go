@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/NETWAYS/check_system_basics/internal/netdev"
+
+	"github.com/NETWAYS/go-check"
+)
+
+var (
+	testIface = netdev.IfaceData{
+		Name:      "eth0",
+		Operstate: netdev.Up,
+		Speed:     1000,
+	}
+)
+
+func TestNetdevDirectionWithoutThresholds(t *testing.T) {
+	rates := netdev.IfaceRates{}
+	config := netdev.CheckConfig{}
+
+	rx := computeNetdevDirection(&testIface, &rates, netdevRx, &config)
+
+	if check.OK != rx.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.OK, rx.GetStatus())
+	}
+}
+
+func TestNetdevDirectionUtilization(t *testing.T) {
+	rates := netdev.IfaceRates{}
+	rates[0] = 900 * 1000 * 1000 / 8 // rx_bytes
+
+	config := netdev.CheckConfig{}
+	_ = config.RxUtilization.Warn.Set("80")
+	_ = config.RxUtilization.Crit.Set("95")
+
+	rx := computeNetdevDirection(&testIface, &rates, netdevRx, &config)
+
+	if check.Warning != rx.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.Warning, rx.GetStatus())
+	}
+
+	tx := computeNetdevDirection(&testIface, &rates, netdevTx, &config)
+
+	if check.OK != tx.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.OK, tx.GetStatus())
+	}
+
+	// Without a known link speed the utilization can not be evaluated
+	virtualIface := testIface
+	virtualIface.Speed = netdev.SpeedUnknown
+
+	rx = computeNetdevDirection(&virtualIface, &rates, netdevRx, &config)
+
+	if check.OK != rx.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.OK, rx.GetStatus())
+	}
+}
+
+func TestNetdevDirectionErrors(t *testing.T) {
+	rates := netdev.IfaceRates{}
+	rates[1] = 10 // rx_errors
+	rates[3] = 90 // rx_packets
+
+	config := netdev.CheckConfig{}
+	_ = config.ErrorRatio.Crit.Set("5")
+
+	rx := computeNetdevDirection(&testIface, &rates, netdevRx, &config)
+
+	if check.Critical != rx.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.Critical, rx.GetStatus())
+	}
+}
+
+func TestNetdevStatisticRates(t *testing.T) {
+	rates := netdev.IfaceRates{}
+
+	config := netdev.CheckConfig{}
+	_ = config.WarningStatisticRate.Set("rx_fifo_errors=@0:1")
+
+	err := validateNetdevOptions(&config)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	results := computeNetdevStatisticRates(&testIface, &rates, &config)
+
+	if len(results) != 1 || check.Warning != results[0].GetStatus() {
+		t.Fatalf("expected one result with %v, got %v", check.Warning, results)
+	}
+
+	_ = config.CriticalStatisticRate.Set("rx_unknown=1")
+
+	err = validateNetdevOptions(&config)
+	if err == nil {
+		t.Fatalf("expected an error for an unknown statistic")
+	}
+}
+
+func TestNetdevCount(t *testing.T) {
+	config := netdev.CheckConfig{}
+	config.Filters.IncludeInterfaceNames = []string{"^ens1$", "^ens2$"}
+	_ = config.CriticalTotalCountOfInterfaces.Set("2:2")
+
+	interfaces := []netdev.IfaceData{
+		{Name: "ens1", Operstate: netdev.Up},
+	}
+
+	countResult := computeNetdevCount(interfaces, &config)
+
+	if check.Critical != countResult.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.Critical, countResult.GetStatus())
+	}
+
+	interfaces = append(interfaces, netdev.IfaceData{Name: "ens2", Operstate: netdev.Down})
+
+	countResult = computeNetdevCount(interfaces, &config)
+
+	if check.Critical != countResult.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.Critical, countResult.GetStatus())
+	}
+
+	expected := "[CRITICAL] Found 2 matching interfaces (1 up). This violates the expected number of 2:2 interfaces up. " +
+		"^ens1$: 1 found, 1 up; ^ens2$: 1 found, 0 up (not up: ens2)"
+	if !strings.HasPrefix(countResult.String(), expected) {
+		t.Fatalf("expected %v, got %v", expected, countResult.String())
+	}
+
+	interfaces[1].Operstate = netdev.Up
+
+	countResult = computeNetdevCount(interfaces, &config)
+
+	if check.OK != countResult.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.OK, countResult.GetStatus())
+	}
+}
+
+func TestValidateNetdevInterfaceTypes(t *testing.T) {
+	config := netdev.CheckConfig{}
+	config.Filters.IncludeInterfaceTypes = []string{netdev.KindPhysical}
+
+	if err := validateNetdevOptions(&config); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	config.Filters.ExcludeInterfaceTypes = []string{"docker"}
+
+	if err := validateNetdevOptions(&config); err == nil {
+		t.Fatalf("expected an error for an unknown interface type")
+	}
+}
+
+func TestNetdevLink(t *testing.T) {
+	iface := testIface
+	iface.Duplex = netdev.DuplexHalf
+	iface.MTU = 1500
+
+	config := netdev.CheckConfig{}
+
+	link := computeNetdevLink(&iface, nil, &config)
+
+	if check.OK != link.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.OK, link.GetStatus())
+	}
+
+	_ = config.MinSpeed.Set("^eth=10000")
+
+	link = computeNetdevLink(&iface, nil, &config)
+
+	if check.Warning != link.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.Warning, link.GetStatus())
+	}
+
+	// The last matching expectation wins
+	_ = config.MinSpeed.Set("^eth0$=1000")
+	config.FullDuplex = []string{"^eth"}
+	config.LinkMismatchIsCritical = true
+
+	link = computeNetdevLink(&iface, nil, &config)
+
+	expected := "[CRITICAL] Link: 1000 Mbit/s, half duplex, MTU 1500, carrier down, 0 carrier changes (expected full duplex)"
+	if link.String() != expected {
+		t.Fatalf("expected %v, got %v", expected, link.String())
+	}
+}
+
+func TestNetdevCarrierChanges(t *testing.T) {
+	config := netdev.CheckConfig{}
+	_ = config.CarrierChanges.Crit.Set("2")
+
+	changes := uint64(3)
+
+	link := computeNetdevLink(&testIface, &changes, &config)
+
+	if check.Critical != link.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.Critical, link.GetStatus())
+	}
+
+	// Without a previous execution the threshold can not be evaluated
+	link = computeNetdevLink(&testIface, nil, &config)
+
+	if check.OK != link.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.OK, link.GetStatus())
+	}
+}
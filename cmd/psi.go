@@ -138,33 +138,35 @@ var psiCmd = &cobra.Command{
 \_ [WARNING] IO Pressure - Avg10: 0.00, Avg60: 0.00, Avg300: 0.00
 \_ [WARNING] Memory Pressure - Avg10: 0.00, Avg60: 0.00, Avg300: 0.00
 |cpu-some-avg10=0%;30:80;@95:100;0;100 cpu-some-avg60=0.02%;30:80;@95:100;0;100 cpu-some-avg300=0.02%;30:80;@95:100;0;100 cpu-some-total=33046682c;30:80;@95:100;0 cpu-full-avg10=0%;30:80;@31:81;0;100 cpu-full-avg60=0%;30:80;@95:100;0;100 cpu-full-avg300=0%;;;0;100 cpu-full-total=0c;;;0 io-some-avg10=0%;11:99;@95:100;0;100 io-some-avg60=0%;11:99;@95:100;0;100 io-some-avg300=0%;11:99;@49:51;0;100 io-some-total=27037011c;11:99;@95:100;0 io-full-avg10=0%;11:99;@95:100;0;100 io-full-avg60=0%;11:99;@95:100;0;100 io-full-avg300=0%;;;0;100 io-full-total=26265104c;;;0 memory-some-avg10=0%;@23;@95:100;0;100 memory-some-avg60=0%;@23;@95:100;0;100 memory-some-avg300=0%;@23;@95:100;0;100 memory-some-total=354c;@23;@95:100;0 memory-full-avg10=0%;@23;@95:100;0;100 memory-full-avg60=0%;@23;@95:100;0;100 memory-full-avg300=0%;;;0;100 memory-full-total=193c;;;0`,
-	Run: func(_ *cobra.Command, _ []string) {
-		var overall result.Overall
+	Run: runCheck(psiCheck),
+}
 
-		// If no mode is selected, select all
-		if !config.IncludeCPU && !config.IncludeIO && !config.IncludeMemory {
-			config.IncludeCPU = true
-			config.IncludeIO = true
-			config.IncludeMemory = true
-		}
+func psiCheck(_ *cobra.Command) ([]*result.PartialResult, error) {
+	results := make([]*result.PartialResult, 0, 3)
 
-		// CPU Pressure
-		if config.IncludeCPU {
-			overall.AddSubcheck(checkPsiCPUPressure(&config))
-		}
+	// If no mode is selected, select all
+	if !config.IncludeCPU && !config.IncludeIO && !config.IncludeMemory {
+		config.IncludeCPU = true
+		config.IncludeIO = true
+		config.IncludeMemory = true
+	}
 
-		// IO Pressure
-		if config.IncludeIO {
-			overall.AddSubcheck(checkPsiIoPressure(&config))
-		}
+	// CPU Pressure
+	if config.IncludeCPU {
+		results = append(results, checkPsiCPUPressure(&config))
+	}
 
-		// Memory Pressure
-		if config.IncludeMemory {
-			overall.AddSubcheck(checkPsiMemoryPressure(&config))
-		}
+	// IO Pressure
+	if config.IncludeIO {
+		results = append(results, checkPsiIoPressure(&config))
+	}
+
+	// Memory Pressure
+	if config.IncludeMemory {
+		results = append(results, checkPsiMemoryPressure(&config))
+	}
 
-		check.Exit(overall.GetStatus(), overall.GetOutput())
-	},
+	return results, nil
 }
 
 func init() {
@@ -232,6 +234,8 @@ func init() {
 	psiFs.BoolVar(&config.IncludeCPU, "include-cpu", false, "Include CPU values explicitly (by default all are included)")
 	psiFs.BoolVar(&config.IncludeMemory, "include-memory", false, "Include Memory values explicitly (by default all are included)")
 	psiFs.BoolVar(&config.IncludeIO, "include-io", false, "Include IO values explicitly (by default all are included)")
+
+	registerCheck(psiCmd, psiCheck)
 }
 
 func checkPsiCPUPressure(config *psiConfig) *result.PartialResult {
@@ -249,7 +253,10 @@ func checkPsiCPUPressure(config *psiConfig) *result.PartialResult {
 			return cpuCheck
 		}
 
-		check.ExitError(err)
+		cpuCheck.SetState(check.Unknown)
+		cpuCheck.SetOutput(fmt.Sprintf("Could not read the CPU pressure: %s", err))
+
+		return cpuCheck
 	}
 
 	cpuCheckPerfdata := *psiCPU.Perfdata()
@@ -394,7 +401,10 @@ func checkPsiIoPressure(config *psiConfig) *result.PartialResult {
 			return ioCheck
 		}
 
-		check.ExitError(err)
+		ioCheck.SetState(check.Unknown)
+		ioCheck.SetOutput(fmt.Sprintf("Could not read the IO pressure: %s", err))
+
+		return ioCheck
 	}
 
 	ioCheckPerfdata := *psiIo.Perfdata()
@@ -533,12 +543,15 @@ func checkPsiMemoryPressure(config *psiConfig) *result.PartialResult {
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
 			memoryCheck.SetState(check.Unknown)
-			memoryCheck.SetOutput("IO pressure file not found. Perhaps the PSI interface is not active on this system? It might be necessary to change the kernel config")
+			memoryCheck.SetOutput("Memory pressure file not found. Perhaps the PSI interface is not active on this system? It might be necessary to change the kernel config")
 
 			return memoryCheck
 		}
 
-		check.ExitError(err)
+		memoryCheck.SetState(check.Unknown)
+		memoryCheck.SetOutput(fmt.Sprintf("Could not read the memory pressure: %s", err))
+
+		return memoryCheck
 	}
 
 	memoryCheckPerfdata := *psiMemory.Perfdata()
@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/NETWAYS/check_system_basics/internal/common/thresholds"
+	"github.com/NETWAYS/check_system_basics/internal/raid"
+	"github.com/NETWAYS/go-check"
+	"github.com/NETWAYS/go-check/convert"
+	"github.com/NETWAYS/go-check/result"
+	"github.com/spf13/cobra"
+)
+
+var RaidConfig raid.CheckConfig
+
+var raidCmd = &cobra.Command{
+	Use:   "raid",
+	Short: "Submodule to check the state of software RAID (md) arrays",
+	Long: `Submodule to check the state of software RAID (md) arrays from /proc/mdstat and /sys/block/md*/md.
+Inactive arrays, degraded arrays and faulty members are CRITICAL. A running rebuild (resync, recovery
+or reshape) is WARNING, a degraded array stays CRITICAL until the rebuild is finished.`,
+	Example: `./check_system_basics raid
+[CRITICAL] - states: critical=2 ok=1
+\_ [CRITICAL] md2: raid5 active, 2 of 3 devices, degraded by 1, recover 8.60% done, finish in 30s at 29.58MiB/s
+    \_ [OK] sdd1: spare
+    \_ [OK] sdc1: in_sync
+    \_ [OK] sdb1: in_sync
+\_ [CRITICAL] md1: raid1 clean, 1 of 2 devices, degraded by 1, faulty: sdf2
+    \_ [CRITICAL] sdf2: faulty,write_error
+    \_ [OK] sde2: in_sync
+\_ [OK] md0: raid1 clean, 2 of 2 devices, mismatch_cnt 0
+    \_ [OK] sdh1: in_sync
+    \_ [OK] sdg1: in_sync
+|md2_devices=2;;;0;3 md2_degraded=1;;;0;3 md2_mismatch_cnt=0;;;0 md2_sync_progress=8.6%;;;0;100 ...`,
+	Run: runCheck(raidCheck),
+}
+
+func init() {
+	rootCmd.AddCommand(raidCmd)
+
+	fs := raidCmd.Flags()
+
+	fs.StringSliceVar(&RaidConfig.Filters.IncludeArrayNames, "include-array-name", nil,
+		"Explicitly include only arrays whose names match this regexp regex (may be repeated). E.g. '^md0$'")
+	fs.StringSliceVar(&RaidConfig.Filters.ExcludeArrayNames, "exclude-array-name", nil,
+		"Ignore all arrays where the array name matches this regexp regex (may be repeated). E.g. '^md127$'")
+
+	raidThresholds := []thresholds.ThresholdOption{
+		{
+			Th:          &RaidConfig.MismatchCount.Warn,
+			FlagString:  "warning-mismatch-count",
+			Description: "Warning threshold for the number of sectors found to differ by the last check of an array (mismatch_cnt)",
+		},
+		{
+			Th:          &RaidConfig.MismatchCount.Crit,
+			FlagString:  "critical-mismatch-count",
+			Description: "Critical threshold for the number of sectors found to differ by the last check of an array (mismatch_cnt)",
+		},
+	}
+
+	thresholds.AddFlags(fs, &raidThresholds)
+
+	fs.SortFlags = false
+
+	registerCheck(raidCmd, raidCheck)
+}
+
+func raidCheck(_ *cobra.Command) ([]*result.PartialResult, error) {
+	arrays, err := raid.GetAllArrays()
+	if err != nil {
+		return nil, err
+	}
+
+	arrays, err = raid.FilterArrays(arrays, &RaidConfig.Filters)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(arrays) == 0 {
+		noArrays := result.NewPartialResult()
+		noArrays.SetState(check.Unknown)
+		noArrays.SetOutput("No software RAID arrays found")
+
+		return []*result.PartialResult{noArrays}, nil
+	}
+
+	results := make([]*result.PartialResult, 0, len(arrays))
+
+	for i := range arrays {
+		results = append(results, computeArray(&arrays[i], &RaidConfig))
+	}
+
+	return results, nil
+}
+
+func computeArray(array *raid.Array, config *raid.CheckConfig) *result.PartialResult {
+	arrayResult := result.NewPartialResult()
+	arrayResult.SetDefaultState(check.OK)
+
+	output := strings.Builder{}
+	output.WriteString(array.Name + ": ")
+
+	states := []check.Status{check.OK}
+
+	if !array.Active {
+		output.WriteString("inactive")
+
+		states = append(states, check.Critical)
+	} else {
+		output.WriteString(array.Level)
+
+		if array.ArrayState != "" {
+			output.WriteString(" " + array.ArrayState)
+		} else {
+			output.WriteString(" active")
+		}
+	}
+
+	if array.Devices > 0 {
+		output.WriteString(fmt.Sprintf(", %d of %d devices", array.Devices-array.Degraded, array.Devices))
+
+		arrayResult.AddPerfdata(&check.Perfdata{
+			Label: array.Name + "_devices",
+			Value: array.Devices - array.Degraded,
+			Min:   0,
+			Max:   array.Devices,
+		})
+		arrayResult.AddPerfdata(&check.Perfdata{
+			Label: array.Name + "_degraded",
+			Value: array.Degraded,
+			Min:   0,
+			Max:   array.Devices,
+		})
+	}
+
+	if array.Degraded > 0 {
+		output.WriteString(fmt.Sprintf(", degraded by %d", array.Degraded))
+
+		// The array stays degraded until a rebuild is finished
+		states = append(states, check.Critical)
+	}
+
+	if faulty := array.FaultyMembers(); len(faulty) > 0 {
+		output.WriteString(", faulty: " + strings.Join(faulty, ", "))
+
+		states = append(states, check.Critical)
+	}
+
+	if array.SyncAction != "" && array.SyncAction != raid.ActionIdle {
+		output.WriteString(", " + array.SyncAction)
+
+		if array.Progress >= 0 {
+			output.WriteString(fmt.Sprintf(" %.2f%% done", array.Progress))
+
+			if array.Finish > 0 {
+				output.WriteString(fmt.Sprintf(", finish in %s", array.Finish))
+			}
+
+			if array.Speed > 0 {
+				output.WriteString(fmt.Sprintf(" at %s/s", convert.BytesIEC(array.Speed*1024)))
+			}
+
+			arrayResult.AddPerfdata(&check.Perfdata{
+				Label: array.Name + "_sync_progress",
+				Value: array.Progress,
+				Uom:   "%",
+				Min:   0,
+				Max:   100,
+			})
+		} else {
+			output.WriteString(" pending")
+		}
+
+		if array.Rebuilding() {
+			states = append(states, check.Warning)
+		}
+	}
+
+	if array.MismatchCount != nil {
+		pdMismatch := check.Perfdata{
+			Label: array.Name + "_mismatch_cnt",
+			Value: *array.MismatchCount,
+			Min:   0,
+		}
+
+		mismatchState := config.MismatchCount.Evaluate(float64(*array.MismatchCount), &pdMismatch)
+
+		output.WriteString(fmt.Sprintf(", mismatch_cnt %d", *array.MismatchCount))
+
+		if mismatchState != check.OK {
+			output.WriteString(" violates threshold")
+		}
+
+		states = append(states, mismatchState)
+
+		arrayResult.AddPerfdata(&pdMismatch)
+	}
+
+	for i := range array.Members {
+		memberResult := result.NewPartialResult()
+		memberResult.SetOutput(array.Members[i].Name + ": " + strings.Join(array.Members[i].States, ","))
+
+		if array.Members[i].Faulty() {
+			memberResult.SetState(check.Critical)
+		} else {
+			memberResult.SetState(check.OK)
+		}
+
+		arrayResult.AddSubcheck(memberResult)
+	}
+
+	arrayResult.SetState(check.WorstState(states...))
+	arrayResult.SetOutput(output.String())
+
+	return arrayResult
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NETWAYS/check_system_basics/internal/raid"
+	"github.com/NETWAYS/go-check"
+)
+
+func TestComputeArray(t *testing.T) {
+	config := raid.CheckConfig{}
+
+	mismatches := uint64(0)
+
+	array := raid.Array{
+		Name:       "md1",
+		Active:     true,
+		Level:      "raid1",
+		ArrayState: "clean",
+		Devices:    2,
+		Degraded:   1,
+		Members: []raid.Member{
+			{Name: "sdf2", Slot: 1, States: []string{raid.MemberFaulty}},
+			{Name: "sde2", Slot: 0, States: []string{raid.MemberInSync}},
+		},
+		SyncAction:    raid.ActionIdle,
+		Progress:      -1,
+		MismatchCount: &mismatches,
+	}
+
+	result := computeArray(&array, &config)
+
+	expected := "[CRITICAL] md1: raid1 clean, 1 of 2 devices, degraded by 1, faulty: sdf2, mismatch_cnt 0"
+	if result.String() != expected {
+		t.Fatalf("expected %v, got %v", expected, result.String())
+	}
+
+	// The array stays degraded during a rebuild onto a new member
+	array.Members = []raid.Member{
+		{Name: "sdg2", Slot: 1, States: []string{raid.MemberSpare}},
+		{Name: "sde2", Slot: 0, States: []string{raid.MemberInSync}},
+	}
+	array.SyncAction = raid.ActionRecover
+	array.Progress = 8.6
+	array.Finish = 30 * time.Second
+	array.Speed = 30293
+
+	result = computeArray(&array, &config)
+
+	expected = "[CRITICAL] md1: raid1 clean, 1 of 2 devices, degraded by 1, recover 8.60% done, finish in 30s at 29.58MiB/s, mismatch_cnt 0"
+	if result.String() != expected {
+		t.Fatalf("expected %v, got %v", expected, result.String())
+	}
+
+	// A resync of an array which is not degraded is only a warning
+	array.Degraded = 0
+	array.SyncAction = raid.ActionResync
+
+	result = computeArray(&array, &config)
+	if result.GetStatus() != check.Warning {
+		t.Fatalf("expected %v, got %v", check.Warning, result.GetStatus())
+	}
+
+	array.SyncAction = raid.ActionCheck
+	mismatches = 256
+
+	err := config.MismatchCount.Crit.Set("100")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	result = computeArray(&array, &config)
+	if result.GetStatus() != check.Critical {
+		t.Fatalf("expected %v, got %v", check.Critical, result.GetStatus())
+	}
+}
+
+func TestComputeArrayInactive(t *testing.T) {
+	array := raid.Array{
+		Name:     "md127",
+		Progress: -1,
+		Members: []raid.Member{
+			{Name: "sdj", States: []string{raid.MemberSpare}},
+		},
+	}
+
+	result := computeArray(&array, &raid.CheckConfig{})
+
+	if result.String() != "[CRITICAL] md127: inactive" {
+		t.Fatalf("expected %v, got %v", "[CRITICAL] md127: inactive", result.String())
+	}
+}
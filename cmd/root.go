@@ -3,14 +3,21 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"sort"
+	"strings"
 
 	intConfig "github.com/NETWAYS/check_system_basics/internal/common/config"
+	"github.com/NETWAYS/check_system_basics/internal/common/state"
 	"github.com/NETWAYS/go-check"
+	"github.com/NETWAYS/go-check/result"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var Timeout = 30
 var debug = false
+var StateDirectory = state.DefaultDirectory
+var ConfigFile = ""
 
 var (
 	version string
@@ -20,7 +27,14 @@ var rootCmd = &cobra.Command{
 	Use:     "check_system_basics",
 	Short:   "Icinga check plugin to check various Linux metrics",
 	Version: version,
-	PersistentPreRun: func(_ *cobra.Command, _ []string) {
+	PersistentPreRun: func(cmd *cobra.Command, _ []string) {
+		if ConfigFile != "" {
+			err := applyConfigFile(cmd, ConfigFile)
+			if err != nil {
+				check.ExitError(err)
+			}
+		}
+
 		go check.HandleTimeout(Timeout)
 	},
 	Run: RunFunction,
@@ -49,6 +63,10 @@ func init() {
 	pfs.IntVarP(&Timeout, "timeout", "t", Timeout,
 		"Timeout for the check")
 	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "Enable debug output")
+	pfs.StringVar(&StateDirectory, "state-directory", StateDirectory,
+		"Directory where values are stored between executions to compute rates")
+	pfs.StringVar(&ConfigFile, "config", ConfigFile,
+		"Read the flags of the sub commands from this YAML or TOML file. Flags given on the command line take precedence")
 
 	rootCmd.Flags().SortFlags = false
 	pfs.SortFlags = false
@@ -84,3 +102,103 @@ func ConfigDump(cmd *cobra.Command, executableName string) {
 
 	fmt.Println(result)
 }
+
+// applyConfigFile sets the flags of cmd, which were not given on the command line, to the values
+// of the config file. The file contains one section per sub command, the "all" sub command uses
+// the sections of all the sub commands it runs. Every section is validated against the flags of its
+// sub command, so a broken section is reported by every sub command and not only by its own
+func applyConfigFile(cmd *cobra.Command, path string) error {
+	file, err := intConfig.LoadFile(path)
+	if err != nil {
+		return err
+	}
+
+	for _, section := range file.Sections() {
+		sub, _, err := cmd.Root().Find([]string{section})
+		if err != nil || sub == cmd.Root() {
+			return fmt.Errorf("config file: unknown section %q, expected the name of a sub command", section)
+		}
+
+		err = file.Validate(sub.LocalFlags(), section)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, section := range file.Sections() {
+		switch {
+		case section == cmd.Name():
+			err = file.Apply(cmd.Flags(), section, "")
+		case cmd == allCmd:
+			err = file.Apply(cmd.Flags(), section, section+allFlagSeparator)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkFunction collects the results of a sub command, so they can either be reported
+// on their own or combined with the results of other sub commands by the "all" sub command
+type checkFunction func(cmd *cobra.Command) ([]*result.PartialResult, error)
+
+// runCheck returns the Run function of a sub command which reports the results of fn
+func runCheck(fn checkFunction) func(*cobra.Command, []string) {
+	return func(cmd *cobra.Command, _ []string) {
+		results, err := fn(cmd)
+		if err != nil {
+			check.ExitError(err)
+		}
+
+		overall := result.Overall{}
+
+		for i := range results {
+			overall.AddSubcheck(results[i])
+		}
+
+		check.Exit(overall.GetStatus(), overall.GetOutput())
+	}
+}
+
+// openStateStore opens the state of the check with the given name. The state is keyed by the command
+// and the flags set for the check, so differently configured checks do not interfere.
+// If the check is run by the "all" sub command, only the flags of this check are considered
+func openStateStore(cmd *cobra.Command, name string) (*state.Store, error) {
+	command := cmd.CommandPath()
+	prefix := ""
+
+	if cmd.Name() != name {
+		command += " " + name
+		prefix = name + allFlagSeparator
+	}
+
+	args := make([]string, 0)
+
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		switch flag.Name {
+		case "timeout", "debug", "state-directory":
+			return
+		default:
+			if strings.HasPrefix(flag.Name, prefix) {
+				args = append(args, flag.Name+"="+flag.Value.String())
+			}
+		}
+	})
+
+	sort.Strings(args)
+
+	return state.Open(StateDirectory, state.Key(command, args))
+}
+
+// stateNotSaved reports that the state could not be written, which means that the next
+// execution can not compute any rates
+func stateNotSaved(err error) *result.PartialResult {
+	partial := result.NewPartialResult()
+	partial.SetState(check.Unknown)
+	partial.SetOutput(fmt.Sprintf("Could not save the state for the next execution: %s", err))
+
+	return partial
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestApplyConfigFileValidatesAllSections(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	err := os.WriteFile(path, []byte("netdev:\n  include-interface-nam: ^eth\n"), 0o600)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for _, cmd := range []string{"load", "all", "netdev"} {
+		sub, _, err := rootCmd.Find([]string{cmd})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if applyConfigFile(sub, path) == nil {
+			t.Fatalf("expected an error for the unknown key in the netdev section when running %s", cmd)
+		}
+	}
+}
@@ -35,64 +35,71 @@ thresholds respecting the sensor type and the respective specialities`,
     \_ [OK] iwlwifi_1_temp1: Ok - 47C
 |acpitz_temp1=48C;;~:210 BAT1_in0=17.544V BAT1_curr1=0A Composite=37C;~:83;-5:87  'Package id 0'=48C;~:100;~:100 'Core 0'=47C;~:100;~:100 'Core 1'=46C;~:100;~:100 'Core 2'=46C;~:100;~:100 'Core 3'=45C;~:100;~:100 iwlwifi_1_temp1=47C
 `,
-	Run: func(_ *cobra.Command, _ []string) {
-		devices, err := sensors.GetDefaultDevices()
-		if err != nil {
-			check.ExitError(err)
-		}
+	Run: runCheck(sensorsCheck),
+}
 
-		var overall result.Overall
+func sensorsCheck(_ *cobra.Command) ([]*result.PartialResult, error) {
+	devices, err := sensors.GetDefaultDevices()
+	if err != nil {
+		return nil, err
+	}
 
-		if len(devices) == 0 {
-			overall.Add(check.Unknown, "No devices found")
-			check.Exit(overall.GetStatus(), overall.GetOutput())
-		}
+	if len(devices) == 0 {
+		noDevices := result.NewPartialResult()
+		noDevices.SetState(check.Unknown)
+		noDevices.SetOutput("No devices found")
 
-		var (
-			alarms uint = 0
-		)
+		return []*result.PartialResult{noDevices}, nil
+	}
 
-		for _, device := range devices {
-			devicePartial := result.NewPartialResult()
+	results := make([]*result.PartialResult, 0, len(devices))
 
-			devicePartial.SetDefaultState(check.OK)
+	var (
+		alarms uint = 0
+	)
 
-			devicePartial.SetOutput(device.Name)
+	for _, device := range devices {
+		devicePartial := result.NewPartialResult()
 
-			for idx, sensor := range device.Sensors {
-				ssc := result.NewPartialResult()
+		devicePartial.SetDefaultState(check.OK)
 
-				ssc.SetDefaultState(check.OK)
+		devicePartial.SetOutput(device.Name)
 
-				sensorPerfdata := &(device.Sensors[idx]).Perfdata
-				ssc.AddPerfdata(sensorPerfdata)
+		for idx, sensor := range device.Sensors {
+			ssc := result.NewPartialResult()
 
-				preliminaryOutput := ""
-				if sensor.Alarm {
-					preliminaryOutput = "Alarm!"
+			ssc.SetDefaultState(check.OK)
 
-					ssc.SetState(check.Critical)
+			sensorPerfdata := &(device.Sensors[idx]).Perfdata
+			ssc.AddPerfdata(sensorPerfdata)
 
-					alarms++
-				} else {
-					preliminaryOutput = "Ok"
+			preliminaryOutput := ""
+			if sensor.Alarm {
+				preliminaryOutput = "Alarm!"
 
-					ssc.SetState(check.OK)
-				}
+				ssc.SetState(check.Critical)
 
-				// Add perfdata label (sensor name) to ouptput to make it more descriptive
-				ssc.SetOutput(fmt.Sprintf("%s: %s - %v%s", sensorPerfdata.Label, preliminaryOutput, sensorPerfdata.Value, sensorPerfdata.Uom))
+				alarms++
+			} else {
+				preliminaryOutput = "Ok"
 
-				devicePartial.AddSubcheck(ssc)
+				ssc.SetState(check.OK)
 			}
 
-			overall.AddSubcheck(devicePartial)
+			// Add perfdata label (sensor name) to ouptput to make it more descriptive
+			ssc.SetOutput(fmt.Sprintf("%s: %s - %v%s", sensorPerfdata.Label, preliminaryOutput, sensorPerfdata.Value, sensorPerfdata.Uom))
+
+			devicePartial.AddSubcheck(ssc)
 		}
 
-		check.Exit(overall.GetStatus(), overall.GetOutput())
-	},
+		results = append(results, devicePartial)
+	}
+
+	return results, nil
 }
 
 func init() {
 	rootCmd.AddCommand(sensorsCmd)
+
+	registerCheck(sensorsCmd, sensorsCheck)
 }
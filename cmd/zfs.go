@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NETWAYS/check_system_basics/internal/common/thresholds"
+	"github.com/NETWAYS/check_system_basics/internal/zfs"
+	"github.com/NETWAYS/go-check"
+	"github.com/NETWAYS/go-check/convert"
+	"github.com/NETWAYS/go-check/result"
+	"github.com/spf13/cobra"
+)
+
+var ZfsConfig zfs.CheckConfig
+
+var zfsCmd = &cobra.Command{
+	Use:   "zfs",
+	Short: "Submodule to check the health and the capacity of ZFS pools",
+	Long: `Submodule to check the health and the capacity of ZFS pools.
+The state of the pools, their I/O statistics and the ARC statistics are read from /proc/spl/kstat/zfs,
+the capacity is determined with "zpool list". Every pool which is not ONLINE is CRITICAL.`,
+	Example: `./check_system_basics zfs
+[CRITICAL] - states: critical=1 ok=2
+\_ [CRITICAL] backup: DEGRADED, 3.3TiB of 3.6TiB allocated (90.00%) violates threshold
+\_ [OK] tank: ONLINE, 186.3GiB of 931.5GiB allocated (20.00%)
+\_ [OK] ARC: 3.75GiB (target 4GiB, max 8GiB), 90.00% hits
+|backup_allocated_percentage=90%;0:80;0:90;0;100 backup_allocated=3600708327014B;;;0;4000787030016 ...`,
+	Run: runCheck(zfsCheck),
+}
+
+func init() {
+	rootCmd.AddCommand(zfsCmd)
+
+	fs := zfsCmd.Flags()
+
+	fs.StringSliceVar(&ZfsConfig.Filters.IncludePoolNames, "include-pool-name", nil,
+		"Explicitly include only pools whose names match this regexp regex (may be repeated). E.g. 'tank', '^data'")
+	fs.StringSliceVar(&ZfsConfig.Filters.ExcludePoolNames, "exclude-pool-name", nil,
+		"Ignore all pools where the pool name matches this regexp regex (may be repeated). E.g. 'tank', '^data'")
+	fs.StringVar(&ZfsConfig.ZpoolCommand, "zpool-command", "zpool",
+		"The zpool command used to determine the capacity of the pools. The capacity is not checked if it is empty")
+
+	zfsThresholds := []thresholds.ThresholdOption{
+		{
+			Th:          &ZfsConfig.Capacity.Warn,
+			FlagString:  "warning-capacity",
+			Description: "Warning threshold for the allocated space of a pool in percent",
+			Default: thresholds.ThresholdWrapper{
+				IsSet: true,
+				Th: check.Threshold{
+					Lower: 0,
+					Upper: 80,
+				},
+			},
+		},
+		{
+			Th:          &ZfsConfig.Capacity.Crit,
+			FlagString:  "critical-capacity",
+			Description: "Critical threshold for the allocated space of a pool in percent",
+			Default: thresholds.ThresholdWrapper{
+				IsSet: true,
+				Th: check.Threshold{
+					Lower: 0,
+					Upper: 90,
+				},
+			},
+		},
+	}
+
+	thresholds.AddFlags(fs, &zfsThresholds)
+
+	fs.SortFlags = false
+
+	registerCheck(zfsCmd, zfsCheck)
+}
+
+func zfsCheck(_ *cobra.Command) ([]*result.PartialResult, error) {
+	pools, err := zfs.GetAllPools()
+	if err != nil {
+		return nil, err
+	}
+
+	pools, err = zfs.FilterPools(pools, &ZfsConfig.Filters)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pools) == 0 {
+		noPools := result.NewPartialResult()
+		noPools.SetState(check.Unknown)
+		noPools.SetOutput("No ZFS pools found")
+
+		return []*result.PartialResult{noPools}, nil
+	}
+
+	results := make([]*result.PartialResult, 0, len(pools)+2)
+
+	if ZfsConfig.ZpoolCommand != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(Timeout)*time.Second/2)
+		defer cancel()
+
+		err = zfs.GetCapacities(ctx, ZfsConfig.ZpoolCommand, pools)
+		if err != nil {
+			capacityResult := result.NewPartialResult()
+			capacityResult.SetState(check.Unknown)
+			capacityResult.SetOutput(err.Error())
+
+			results = append(results, capacityResult)
+		}
+	}
+
+	for i := range pools {
+		results = append(results, computePool(&pools[i], &ZfsConfig))
+	}
+
+	arc, err := zfs.GetARCStats()
+	if err != nil {
+		return nil, err
+	}
+
+	if arc != nil {
+		results = append(results, computeARC(arc))
+	}
+
+	return results, nil
+}
+
+// poolState maps the state of a pool to the state of the check
+func poolState(state string) check.Status {
+	switch state {
+	case zfs.StateOnline:
+		return check.OK
+	case zfs.StateDegraded, zfs.StateFaulted, zfs.StateOffline, zfs.StateUnavail, zfs.StateRemoved, zfs.StateSuspend:
+		return check.Critical
+	default:
+		return check.Unknown
+	}
+}
+
+func computePool(pool *zfs.Pool, config *zfs.CheckConfig) *result.PartialResult {
+	poolResult := result.NewPartialResult()
+	poolResult.SetDefaultState(check.OK)
+
+	state := poolState(pool.State)
+
+	output := pool.Name + ": "
+	if pool.State == "" {
+		output += "state unknown"
+	} else {
+		output += pool.State
+	}
+
+	if pool.Capacity != nil {
+		usedPercent := pool.Capacity.UsedPercent()
+
+		output += fmt.Sprintf(", %s of %s allocated (%.2f%%)",
+			convert.BytesIEC(pool.Capacity.Allocated), convert.BytesIEC(pool.Capacity.Size), usedPercent)
+
+		pdPercentage := check.Perfdata{
+			Label: pool.Name + "_allocated_percentage",
+			Value: usedPercent,
+			Uom:   "%",
+			Min:   0,
+			Max:   100,
+		}
+
+		capacityState := config.Capacity.Evaluate(usedPercent, &pdPercentage)
+		if capacityState != check.OK {
+			output += " violates threshold"
+		}
+
+		state = check.WorstState(state, capacityState)
+
+		poolResult.AddPerfdata(&pdPercentage)
+		poolResult.AddPerfdata(&check.Perfdata{
+			Label: pool.Name + "_allocated",
+			Value: pool.Capacity.Allocated,
+			Uom:   "B",
+			Min:   0,
+			Max:   pool.Capacity.Size,
+		})
+	}
+
+	if pool.IO != nil {
+		for _, counter := range []struct {
+			name  string
+			value uint64
+		}{
+			{"read_bytes", pool.IO.ReadBytes},
+			{"written_bytes", pool.IO.WrittenBytes},
+			{"reads", pool.IO.Reads},
+			{"writes", pool.IO.Writes},
+		} {
+			poolResult.AddPerfdata(&check.Perfdata{
+				Label: pool.Name + "_" + counter.name,
+				Value: counter.value,
+				Uom:   "c",
+				Min:   0,
+			})
+		}
+	}
+
+	poolResult.SetState(state)
+	poolResult.SetOutput(output)
+
+	return poolResult
+}
+
+func computeARC(arc *zfs.ARCStats) *result.PartialResult {
+	arcResult := result.NewPartialResult()
+	arcResult.SetDefaultState(check.OK)
+	arcResult.SetOutput(fmt.Sprintf("ARC: %s (target %s, max %s), %.2f%% hits",
+		convert.BytesIEC(arc.Size), convert.BytesIEC(arc.Target), convert.BytesIEC(arc.Max), arc.HitRatio()))
+
+	arcResult.AddPerfdata(&check.Perfdata{
+		Label: "arc_size",
+		Value: arc.Size,
+		Uom:   "B",
+		Min:   0,
+		Max:   arc.Max,
+	})
+	arcResult.AddPerfdata(&check.Perfdata{
+		Label: "arc_target",
+		Value: arc.Target,
+		Uom:   "B",
+		Min:   0,
+		Max:   arc.Max,
+	})
+	arcResult.AddPerfdata(&check.Perfdata{
+		Label: "arc_hits",
+		Value: arc.Hits,
+		Uom:   "c",
+		Min:   0,
+	})
+	arcResult.AddPerfdata(&check.Perfdata{
+		Label: "arc_misses",
+		Value: arc.Misses,
+		Uom:   "c",
+		Min:   0,
+	})
+
+	return arcResult
+}
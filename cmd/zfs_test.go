@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/NETWAYS/check_system_basics/internal/zfs"
+	"github.com/NETWAYS/go-check"
+)
+
+func testZfsConfig(t *testing.T) *zfs.CheckConfig {
+	config := zfs.CheckConfig{}
+
+	err := config.Capacity.Warn.Set("80")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	err = config.Capacity.Crit.Set("90")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	return &config
+}
+
+func TestPoolState(t *testing.T) {
+	config := testZfsConfig(t)
+
+	for state, expected := range map[string]check.Status{
+		zfs.StateOnline:   check.OK,
+		zfs.StateDegraded: check.Critical,
+		zfs.StateFaulted:  check.Critical,
+		"":                check.Unknown,
+	} {
+		pool := zfs.Pool{Name: "tank", State: state}
+
+		result := computePool(&pool, config)
+		if result.GetStatus() != expected {
+			t.Fatalf("expected %v for %q, got %v", expected, state, result.GetStatus())
+		}
+	}
+}
+
+func TestPoolCapacity(t *testing.T) {
+	config := testZfsConfig(t)
+
+	pool := zfs.Pool{
+		Name:     "tank",
+		State:    zfs.StateOnline,
+		Capacity: &zfs.Capacity{Size: 1000, Allocated: 850, Free: 150},
+	}
+
+	result := computePool(&pool, config)
+
+	if check.Warning != result.GetStatus() {
+		t.Fatalf("expected %v, got %v", check.Warning, result.GetStatus())
+	}
+
+	expected := "[WARNING] tank: ONLINE, 850B of 1000B allocated (85.00%) violates threshold"
+	if result.String() != expected {
+		t.Fatalf("expected %v, got %v", expected, result.String())
+	}
+}
+
+func TestARC(t *testing.T) {
+	arc := zfs.ARCStats{Size: 1 << 30, Target: 2 << 30, Max: 4 << 30, Hits: 75, Misses: 25}
+
+	result := computeARC(&arc)
+
+	expected := "[OK] ARC: 1024MiB (target 2GiB, max 4GiB), 75.00% hits"
+	if result.String() != expected {
+		t.Fatalf("expected %v, got %v", expected, result.String())
+	}
+}
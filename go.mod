@@ -3,11 +3,13 @@ module github.com/NETWAYS/check_system_basics
 go 1.25.0
 
 require (
+	github.com/BurntSushi/toml v1.4.0
 	github.com/NETWAYS/go-check v1.0.0
 	github.com/NETWAYS/go-icingadsl v0.1.2
 	github.com/shirou/gopsutil/v3 v3.24.5
 	github.com/spf13/cobra v1.10.2
 	github.com/spf13/pflag v1.0.10
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
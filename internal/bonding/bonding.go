@@ -0,0 +1,235 @@
+package bonding
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/NETWAYS/check_system_basics/internal/common/filter"
+)
+
+const (
+	procBondingPath = "/proc/net/bonding"
+	netDevicePath   = "/sys/class/net"
+)
+
+const (
+	MIIStatusUp = "up"
+)
+
+// Slave describes a single interface which is part of a bond
+type Slave struct {
+	Name             string
+	MIIStatus        string
+	Speed            string
+	Duplex           string
+	LinkFailureCount uint64
+}
+
+// Bond describes a bonding interface and its slaves
+type Bond struct {
+	Name        string
+	Mode        string
+	ActiveSlave string
+	MIIStatus   string
+	MinLinks    uint64
+	Slaves      []Slave
+}
+
+const (
+	BondName = iota
+)
+
+func (b Bond) GetFilterableValue(ident uint) string {
+	switch ident {
+	case BondName:
+		return b.Name
+	default:
+		return ""
+	}
+}
+
+// SlavesUp returns the number of slaves with a MII status of up
+func (b *Bond) SlavesUp() int {
+	count := 0
+
+	for i := range b.Slaves {
+		if b.Slaves[i].MIIStatus == MIIStatusUp {
+			count++
+		}
+	}
+
+	return count
+}
+
+// GetAllBonds returns all the bonding interfaces on the system
+func GetAllBonds() ([]Bond, error) {
+	return getBonds(procBondingPath, netDevicePath)
+}
+
+func getBonds(procPath, sysPath string) ([]Bond, error) {
+	masters, err := os.ReadFile(path.Join(sysPath, "bonding_masters"))
+	if err != nil {
+		// The bonding module is not loaded, so there are no bonds
+		if errors.Is(err, os.ErrNotExist) {
+			return []Bond{}, nil
+		}
+
+		return []Bond{}, err
+	}
+
+	names := strings.Fields(string(masters))
+	result := make([]Bond, 0, len(names))
+
+	for _, name := range names {
+		bond, err := readBond(procPath, sysPath, name)
+		if err != nil {
+			return []Bond{}, err
+		}
+
+		result = append(result, bond)
+	}
+
+	return result, nil
+}
+
+func readBond(procPath, sysPath, name string) (Bond, error) {
+	bond := Bond{Name: name}
+
+	file, err := os.Open(path.Join(procPath, name))
+	if err != nil {
+		return bond, fmt.Errorf("could not read bonding status of %s: %w", name, err)
+	}
+
+	defer file.Close()
+
+	err = parseProcBonding(file, &bond)
+	if err != nil {
+		return bond, fmt.Errorf("could not parse bonding status of %s: %w", name, err)
+	}
+
+	// The sysfs values are preferred, since their format is more stable than the one of the proc file
+	bondingPath := path.Join(sysPath, name, "bonding")
+
+	mode, err := readSysfsValue(bondingPath, "mode")
+	if err == nil {
+		// The mode is given as "name number", e.g. "active-backup 1"
+		bond.Mode = strings.Fields(mode + " ")[0]
+	}
+
+	activeSlave, err := readSysfsValue(bondingPath, "active_slave")
+	if err == nil {
+		bond.ActiveSlave = activeSlave
+	}
+
+	minLinks, err := readSysfsValue(bondingPath, "min_links")
+	if err == nil {
+		bond.MinLinks, err = strconv.ParseUint(minLinks, 10, 64)
+		if err != nil {
+			return bond, fmt.Errorf("could not parse min_links of %s: %w", name, err)
+		}
+	}
+
+	return bond, nil
+}
+
+func readSysfsValue(basePath, name string) (string, error) {
+	content, err := os.ReadFile(path.Join(basePath, name))
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(content)), nil
+}
+
+// parseProcBonding parses the content of /proc/net/bonding/<bond>.
+// The file consists of a general section about the bond followed by one section per slave,
+// each starting with "Slave Interface:"
+func parseProcBonding(reader io.Reader, bond *Bond) error {
+	scanner := bufio.NewScanner(reader)
+
+	var slave *Slave
+
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), ":")
+		if !found {
+			continue
+		}
+
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		if key == "Slave Interface" {
+			bond.Slaves = append(bond.Slaves, Slave{Name: value})
+			slave = &bond.Slaves[len(bond.Slaves)-1]
+
+			continue
+		}
+
+		if slave == nil {
+			switch key {
+			case "Bonding Mode":
+				bond.Mode = value
+			case "Currently Active Slave":
+				bond.ActiveSlave = value
+			case "MII Status":
+				bond.MIIStatus = value
+			}
+
+			continue
+		}
+
+		switch key {
+		case "MII Status":
+			slave.MIIStatus = value
+		case "Speed":
+			slave.Speed = value
+		case "Duplex":
+			slave.Duplex = value
+		case "Link Failure Count":
+			count, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return err
+			}
+
+			slave.LinkFailureCount = count
+		}
+	}
+
+	return scanner.Err()
+}
+
+func FilterBonds(bonds []Bond, filters *Filter) ([]Bond, error) {
+	result, err := filter.Filter(bonds,
+		&filters.IncludeBondNames,
+		BondName,
+		filter.Options{
+			MatchIncludedInResult: true,
+			RegexpMatching:        true,
+			EmptyFilterNoMatch:    false,
+		},
+	)
+	if err != nil {
+		return []Bond{}, err
+	}
+
+	result, err = filter.Filter(result,
+		&filters.ExcludeBondNames,
+		BondName,
+		filter.Options{
+			MatchIncludedInResult: false,
+			RegexpMatching:        true,
+			EmptyFilterNoMatch:    false,
+		},
+	)
+	if err != nil {
+		return []Bond{}, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,58 @@
+package bonding
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetBonds(t *testing.T) {
+	bonds, err := getBonds("testdata/proc", "testdata/sys")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := []Bond{
+		{
+			Name:        "bond0",
+			Mode:        "active-backup",
+			ActiveSlave: "eno1",
+			MIIStatus:   "up",
+			Slaves: []Slave{
+				{Name: "eno1", MIIStatus: "up", Speed: "10000 Mbps", Duplex: "full", LinkFailureCount: 0},
+				{Name: "eno2", MIIStatus: "down", Speed: "Unknown", Duplex: "Unknown", LinkFailureCount: 3},
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(expected, bonds) {
+		t.Fatalf("expected %v, got %v", expected, bonds)
+	}
+
+	if bonds[0].SlavesUp() != 1 {
+		t.Fatalf("expected %v, got %v", 1, bonds[0].SlavesUp())
+	}
+}
+
+func TestGetBondsWithoutBondingModule(t *testing.T) {
+	bonds, err := getBonds("testdata/proc", "testdata/nonexistent")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(bonds) != 0 {
+		t.Fatalf("expected no bonds, got %v", bonds)
+	}
+}
+
+func TestFilterBonds(t *testing.T) {
+	bonds := []Bond{{Name: "bond0"}, {Name: "bond1"}}
+
+	result, err := FilterBonds(bonds, &Filter{ExcludeBondNames: []string{"^bond1$"}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(result) != 1 || result[0].Name != "bond0" {
+		t.Fatalf("expected bond0, got %v", result)
+	}
+}
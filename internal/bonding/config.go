@@ -0,0 +1,21 @@
+package bonding
+
+import (
+	"github.com/NETWAYS/check_system_basics/internal/common/thresholds"
+)
+
+type CheckConfig struct {
+	SlavesUp thresholds.Thresholds
+	// Link failures of a slave since the previous execution
+	LinkFailures thresholds.Thresholds
+
+	// State if the active slave changed since the previous execution
+	ActiveSlaveChangedState string
+
+	Filters Filter
+}
+
+type Filter struct {
+	IncludeBondNames []string
+	ExcludeBondNames []string
+}
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// File is the content of a configuration file. Every section contains the values for one
+// sub command, the keys are the names of the flags of the sub command, e.g.
+//
+//	load:
+//	  load1-warning: 4
+//	netdev:
+//	  include-interface-name: ["^ens", "^eth"]
+type File map[string]map[string]any
+
+// LoadFile reads a YAML (.yaml, .yml) or TOML (.toml) configuration file
+func LoadFile(path string) (File, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return parseYAML(content)
+	case ".toml":
+		return parseTOML(content)
+	default:
+		return nil, fmt.Errorf("unknown config file format %q, expected .yaml, .yml or .toml", filepath.Ext(path))
+	}
+}
+
+func parseYAML(content []byte) (File, error) {
+	file := File{}
+
+	err := yaml.Unmarshal(content, &file)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse config file: %w", err)
+	}
+
+	return file, nil
+}
+
+func parseTOML(content []byte) (File, error) {
+	raw := map[string]any{}
+
+	err := toml.Unmarshal(content, &raw)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse config file: %w", err)
+	}
+
+	file := File{}
+
+	for name, section := range raw {
+		values, ok := section.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("config file: %q is not a section", name)
+		}
+
+		file[name] = values
+	}
+
+	return file, nil
+}
+
+// Sections returns the sorted names of the sections in the file
+func (f File) Sections() []string {
+	return sortedKeys(f)
+}
+
+// Validate checks, without setting any flag, that every key of the given section is the name of a flag
+// in fs and that its value can be converted to flag values
+func (f File) Validate(fs *pflag.FlagSet, section string) error {
+	values := f[section]
+
+	for _, key := range sortedKeys(values) {
+		if fs.Lookup(key) == nil {
+			return fmt.Errorf("config file: unknown key %q in section %q", key, section)
+		}
+
+		_, err := toStrings(values[key])
+		if err != nil {
+			return fmt.Errorf("config file: invalid value for %q in section %q: %w", key, section, err)
+		}
+	}
+
+	return nil
+}
+
+// Apply sets the flags in fs to the values of the given section. The flag names are the keys
+// of the section with prefix prepended.
+// Flags which were already given on the command line are not changed.
+func (f File) Apply(fs *pflag.FlagSet, section, prefix string) error {
+	values := f[section]
+
+	for _, key := range sortedKeys(values) {
+		flag := fs.Lookup(prefix + key)
+		if flag == nil {
+			return fmt.Errorf("config file: unknown key %q in section %q", key, section)
+		}
+
+		if flag.Changed {
+			continue
+		}
+
+		list, err := toStrings(values[key])
+		if err != nil {
+			return fmt.Errorf("config file: invalid value for %q in section %q: %w", key, section, err)
+		}
+
+		for _, value := range list {
+			err = fs.Set(flag.Name, value)
+			if err != nil {
+				return fmt.Errorf("config file: invalid value %q for %q in section %q: %w", value, key, section, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// toStrings converts a single value or a list of values to the string representation of flags
+func toStrings(value any) ([]string, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, errors.New("no value given")
+	case []any:
+		result := make([]string, 0, len(v))
+
+		for i := range v {
+			single, err := toStrings(v[i])
+			if err != nil {
+				return nil, err
+			}
+
+			if len(single) != 1 {
+				return nil, errors.New("nested lists are not supported")
+			}
+
+			result = append(result, single[0])
+		}
+
+		return result, nil
+	case map[string]any:
+		return nil, errors.New("expected a value or a list of values")
+	default:
+		return []string{fmt.Sprint(v)}, nil
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
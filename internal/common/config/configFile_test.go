@@ -0,0 +1,142 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/NETWAYS/check_system_basics/internal/common/thresholds"
+	"github.com/spf13/pflag"
+)
+
+func testFlagSet() (*pflag.FlagSet, *thresholds.ThresholdWrapper, *[]string) {
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+
+	warning := &thresholds.ThresholdWrapper{}
+	names := &[]string{}
+
+	fs.Var(warning, "load1-warning", "")
+	fs.StringSliceVar(names, "include-interface-name", nil, "")
+
+	return fs, warning, names
+}
+
+func TestApplyYAML(t *testing.T) {
+	file, err := parseYAML([]byte(`
+load:
+  load1-warning: 4
+netdev:
+  include-interface-name: ["^ens", "^eth"]
+`))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := []string{"load", "netdev"}
+	if !reflect.DeepEqual(expected, file.Sections()) {
+		t.Fatalf("expected %v, got %v", expected, file.Sections())
+	}
+
+	fs, warning, names := testFlagSet()
+
+	err = file.Apply(fs, "load", "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	err = file.Apply(fs, "netdev", "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !warning.IsSet || warning.Th.Upper != 4 {
+		t.Fatalf("expected the warning threshold to be 4, got %v", warning)
+	}
+
+	if !reflect.DeepEqual([]string{"^ens", "^eth"}, *names) {
+		t.Fatalf("expected %v, got %v", []string{"^ens", "^eth"}, *names)
+	}
+}
+
+func TestApplyTOMLWithPrefix(t *testing.T) {
+	file, err := parseTOML([]byte(`
+[load]
+warning = "10:20"
+`))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	fs, warning, _ := testFlagSet()
+
+	err = file.Apply(fs, "load", "load1-")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if warning.Th.Lower != 10 || warning.Th.Upper != 20 {
+		t.Fatalf("expected the warning threshold to be 10:20, got %v", warning)
+	}
+}
+
+func TestApplyCommandLinePrecedence(t *testing.T) {
+	file, _ := parseYAML([]byte("load:\n  load1-warning: 4\n"))
+
+	fs, warning, _ := testFlagSet()
+
+	_ = fs.Parse([]string{"--load1-warning", "8"})
+
+	err := file.Apply(fs, "load", "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if warning.Th.Upper != 8 {
+		t.Fatalf("expected the command line value 8, got %v", warning)
+	}
+}
+
+func TestApplyInvalid(t *testing.T) {
+	testCases := []string{
+		"load:\n  load7-warning: 4\n",
+		"load:\n  load1-warning: abc\n",
+		"load:\n  load1-warning:\n",
+		"load:\n  load1-warning:\n    upper: 4\n",
+	}
+
+	for _, content := range testCases {
+		file, err := parseYAML([]byte(content))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		fs, _, _ := testFlagSet()
+
+		err = file.Apply(fs, "load", "")
+		if err == nil {
+			t.Fatalf("expected an error for %q", content)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	file, err := parseYAML([]byte("load:\n  load1-warning: 4\nnetdev:\n  load7-warning: 4\n"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	fs, warning, _ := testFlagSet()
+
+	err = file.Validate(fs, "load")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if warning.IsSet {
+		t.Fatalf("expected the warning threshold not to be set, got %v", warning)
+	}
+
+	err = file.Validate(fs, "netdev")
+	if err == nil {
+		t.Fatal("expected an error for an unknown key")
+	}
+}
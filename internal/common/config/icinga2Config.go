@@ -11,6 +11,26 @@ import (
 
 var ccaFlags []icingadsl.CheckCommandArgument
 
+// RepeatableValue is implemented by custom flag values which may be given several times,
+// every occurrence adds to the value instead of replacing it. The key of such a flag is
+// repeated in the generated CheckCommand like the key of the slice flags of pflag
+type RepeatableValue interface {
+	pflag.Value
+	IsRepeatable() bool
+}
+
+// isRepeatable returns whether the flag may be given several times
+func isRepeatable(value pflag.Value) bool {
+	switch value.Type() {
+	case "stringSlice", "ipNetSlice":
+		return true
+	}
+
+	repeatable, ok := value.(RepeatableValue)
+
+	return ok && repeatable.IsRepeatable()
+}
+
 func GenerateIcinga2Config(cmd *cobra.Command, commandName, executableName string, _ bool) string {
 	checkCommands := make([]icingadsl.CheckCommand, 0)
 
@@ -98,11 +118,11 @@ func GenerateIcinga2CheckCommandArgument(flags *pflag.Flag, returnList *[]icinga
 	cca.Name = "--" + flags.Name
 	cca.Description = icingadsl.String(flags.Usage)
 
-	switch flags.Value.Type() {
-	case "bool":
+	switch {
+	case flags.Value.Type() == "bool":
 		cca.SetIf = icingadsl.String(flags.Name)
 		cca.SkipKey = false
-	case "stringSlice":
+	case isRepeatable(flags.Value):
 		cca.RepeatKey = true
 		cca.Value = flags.Name
 	default:
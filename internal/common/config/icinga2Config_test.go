@@ -0,0 +1,32 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/NETWAYS/check_system_basics/internal/common/thresholds"
+	"github.com/NETWAYS/go-icingadsl"
+)
+
+func TestGenerateIcinga2CheckCommandArgumentRepeatable(t *testing.T) {
+	fs, _, _ := testFlagSet()
+
+	named := thresholds.NamedThresholds{}
+	fs.Var(&named, "warning-statistic-rate", "")
+
+	for name, expected := range map[string]bool{
+		"load1-warning":          false,
+		"include-interface-name": true,
+		"warning-statistic-rate": true,
+	} {
+		args := make([]icingadsl.CheckCommandArgument, 0)
+
+		err := GenerateIcinga2CheckCommandArgument(fs.Lookup(name), &args)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if args[0].RepeatKey != expected {
+			t.Fatalf("expected %v for %s, got %v", expected, name, args[0].RepeatKey)
+		}
+	}
+}
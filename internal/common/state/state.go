@@ -0,0 +1,364 @@
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/host"
+)
+
+// DefaultDirectory is the place where the state files are stored if nothing else is configured
+const DefaultDirectory = "/var/tmp/check_system_basics"
+
+// lockTimeout is the maximum duration to wait for a concurrent execution to release the state file
+const lockTimeout = 10 * time.Second
+
+var (
+	// ErrNoPreviousValue is returned if there is no value from a previous run to compare with
+	ErrNoPreviousValue = errors.New("no value from a previous run available")
+	// ErrCounterReset is returned if a counter is smaller than in the previous run (overflow or reset)
+	ErrCounterReset = errors.New("counter was reset or wrapped since the previous run")
+	// ErrNoTimeElapsed is returned if the previous run happened at the same time (or in the future)
+	ErrNoTimeElapsed = errors.New("no time elapsed since the previous run")
+	// ErrLocked is returned if the state file could not be locked in time
+	ErrLocked = errors.New("state file is locked by another execution")
+)
+
+// Snapshot is the data persisted between two executions
+type Snapshot struct {
+	Timestamp time.Time           `json:"timestamp"`
+	BootTime  uint64              `json:"boot_time"`
+	Counters  map[string]uint64   `json:"counters,omitempty"`
+	Values    map[string]float64  `json:"values,omitempty"`
+	Strings   map[string]string   `json:"strings,omitempty"`
+	Series    map[string][]Sample `json:"series,omitempty"`
+}
+
+// Sample is a value recorded at a point in time
+type Sample struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+}
+
+func newSnapshot(now time.Time, bootTime uint64) Snapshot {
+	return Snapshot{
+		Timestamp: now,
+		BootTime:  bootTime,
+		Counters:  make(map[string]uint64),
+		Values:    make(map[string]float64),
+		Strings:   make(map[string]string),
+		Series:    make(map[string][]Sample),
+	}
+}
+
+// Store holds the state of the previous run (if any) and collects the state of the
+// current run, which is written to disk on Close.
+// The state file is locked while the store is open, so concurrent executions of the
+// same check wait for each other instead of overwriting the state.
+type Store struct {
+	path     string
+	lockFile *os.File
+
+	// Previous is nil if there was no usable state, e.g. on the first run or after a reboot
+	Previous *Snapshot
+	Current  Snapshot
+
+	// Rebooted is true if a state was found, but it was recorded before the last reboot
+	Rebooted bool
+
+	// series of the previous run, which are kept across reboots
+	series map[string][]Sample
+}
+
+// Key derives a file name from the name of a check and its arguments, so that
+// differently parameterised executions of the same check do not share a state
+func Key(command string, args []string) string {
+	hash := sha256.New()
+
+	hash.Write([]byte(command))
+
+	for _, arg := range args {
+		hash.Write([]byte{0})
+		hash.Write([]byte(arg))
+	}
+
+	name := strings.ReplaceAll(strings.TrimSpace(command), " ", "_")
+
+	return name + "-" + hex.EncodeToString(hash.Sum(nil))[:16] + ".json"
+}
+
+// Open locks and loads the state file with the given key in directory
+func Open(directory, key string) (*Store, error) {
+	bootTime, err := host.BootTime()
+	if err != nil {
+		return nil, fmt.Errorf("could not determine boot time: %w", err)
+	}
+
+	return open(directory, key, time.Now(), bootTime)
+}
+
+func open(directory, key string, now time.Time, bootTime uint64) (*Store, error) {
+	err := os.MkdirAll(directory, 0o750)
+	if err != nil {
+		return nil, fmt.Errorf("could not create state directory: %w", err)
+	}
+
+	store := &Store{
+		path:    filepath.Join(directory, key),
+		Current: newSnapshot(now, bootTime),
+	}
+
+	store.lockFile, err = lock(store.path + ".lock")
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(store.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return store, nil
+		}
+
+		store.unlock()
+
+		return nil, fmt.Errorf("could not read state file: %w", err)
+	}
+
+	var previous Snapshot
+
+	// A broken state file (e.g. from a full disk) is not fatal, the data is simply collected again
+	if json.Unmarshal(content, &previous) != nil {
+		return store, nil
+	}
+
+	store.series = previous.Series
+
+	// Boot times differ by a second between reads on some systems, so allow a little jitter
+	if previous.BootTime+1 < bootTime || previous.BootTime > bootTime+1 {
+		store.Rebooted = true
+		return store, nil
+	}
+
+	store.Previous = &previous
+
+	return store, nil
+}
+
+func lock(path string) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o640)
+	if err != nil {
+		return nil, fmt.Errorf("could not open lock file: %w", err)
+	}
+
+	deadline := time.Now().Add(lockTimeout)
+
+	for {
+		err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			return file, nil
+		}
+
+		if !errors.Is(err, syscall.EWOULDBLOCK) || time.Now().After(deadline) {
+			file.Close()
+
+			if errors.Is(err, syscall.EWOULDBLOCK) {
+				return nil, fmt.Errorf("%w: %s", ErrLocked, path)
+			}
+
+			return nil, fmt.Errorf("could not lock state file: %w", err)
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func (s *Store) unlock() {
+	if s.lockFile == nil {
+		return
+	}
+
+	_ = syscall.Flock(int(s.lockFile.Fd()), syscall.LOCK_UN)
+	s.lockFile.Close()
+	s.lockFile = nil
+}
+
+// Close writes the current state atomically to disk and releases the lock
+func (s *Store) Close() error {
+	defer s.unlock()
+
+	content, err := json.Marshal(s.Current)
+	if err != nil {
+		return err
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("could not create temporary state file: %w", err)
+	}
+
+	_, err = tmpFile.Write(content)
+	if err == nil {
+		err = tmpFile.Sync()
+	}
+
+	closeErr := tmpFile.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		return fmt.Errorf("could not write state file: %w", err)
+	}
+
+	err = os.Rename(tmpFile.Name(), s.path)
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		return fmt.Errorf("could not replace state file: %w", err)
+	}
+
+	return nil
+}
+
+// Elapsed returns the time between the previous and the current run
+func (s *Store) Elapsed() (time.Duration, error) {
+	if s.Previous == nil {
+		return 0, ErrNoPreviousValue
+	}
+
+	elapsed := s.Current.Timestamp.Sub(s.Previous.Timestamp)
+	if elapsed <= 0 {
+		return 0, ErrNoTimeElapsed
+	}
+
+	return elapsed, nil
+}
+
+// Delta records the counter value for the current run and returns the
+// difference to the value of the previous run
+func (s *Store) Delta(name string, value uint64) (uint64, error) {
+	s.Current.Counters[name] = value
+
+	if s.Previous == nil {
+		return 0, ErrNoPreviousValue
+	}
+
+	previous, ok := s.Previous.Counters[name]
+	if !ok {
+		return 0, ErrNoPreviousValue
+	}
+
+	// The width of a counter is not known here (some drivers still use 32 bit counters),
+	// therefore a smaller value can not be distinguished from a reset and is not used
+	if value < previous {
+		return 0, ErrCounterReset
+	}
+
+	return value - previous, nil
+}
+
+// Rate records the counter value for the current run and returns the
+// average increase per second since the previous run
+func (s *Store) Rate(name string, value uint64) (float64, error) {
+	delta, err := s.Delta(name, value)
+	if err != nil {
+		return 0, err
+	}
+
+	elapsed, err := s.Elapsed()
+	if err != nil {
+		return 0, err
+	}
+
+	return float64(delta) / elapsed.Seconds(), nil
+}
+
+// SetValue records an arbitrary number for the current run
+func (s *Store) SetValue(name string, value float64) {
+	s.Current.Values[name] = value
+}
+
+// PreviousValue returns a number recorded in the previous run
+func (s *Store) PreviousValue(name string) (float64, bool) {
+	if s.Previous == nil {
+		return 0, false
+	}
+
+	value, ok := s.Previous.Values[name]
+
+	return value, ok
+}
+
+// SetString records an arbitrary string for the current run
+func (s *Store) SetString(name, value string) {
+	s.Current.Strings[name] = value
+}
+
+// PreviousString returns a string recorded in the previous run
+func (s *Store) PreviousString(name string) (string, bool) {
+	if s.Previous == nil {
+		return "", false
+	}
+
+	value, ok := s.Previous.Strings[name]
+
+	return value, ok
+}
+
+// AddSample records the value for the current run in the series with the given name and
+// returns the samples of the series which are not older than window, including the new one.
+// Older samples are dropped. Unlike all other values, series are kept across reboots
+func (s *Store) AddSample(name string, value float64, window time.Duration) []Sample {
+	since := s.Current.Timestamp.Add(-window)
+	samples := make([]Sample, 0, len(s.series[name])+1)
+
+	for _, sample := range s.series[name] {
+		if !sample.Timestamp.Before(since) && sample.Timestamp.Before(s.Current.Timestamp) {
+			samples = append(samples, sample)
+		}
+	}
+
+	samples = append(samples, Sample{Timestamp: s.Current.Timestamp, Value: value})
+	s.Current.Series[name] = samples
+
+	return samples
+}
+
+// KeepSeries carries the samples of the series with the given name which are not older than window
+// over to the current run without recording a new value, e.g. if the value could not be read this time
+func (s *Store) KeepSeries(name string, window time.Duration) {
+	since := s.Current.Timestamp.Add(-window)
+	samples := make([]Sample, 0, len(s.series[name]))
+
+	for _, sample := range s.series[name] {
+		if !sample.Timestamp.Before(since) && sample.Timestamp.Before(s.Current.Timestamp) {
+			samples = append(samples, sample)
+		}
+	}
+
+	if len(samples) > 0 {
+		s.Current.Series[name] = samples
+	}
+}
+
+// Explain returns a human readable reason why a rate could not be computed
+func Explain(err error) string {
+	switch {
+	case errors.Is(err, ErrNoPreviousValue):
+		return "no data from a previous execution, rates will be available with the next execution"
+	case errors.Is(err, ErrCounterReset):
+		return "counters were reset since the previous execution, rates will be available with the next execution"
+	case errors.Is(err, ErrNoTimeElapsed):
+		return "no time elapsed since the previous execution"
+	default:
+		return err.Error()
+	}
+}
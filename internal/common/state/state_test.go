@@ -0,0 +1,193 @@
+package state
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestKey(t *testing.T) {
+	a := Key("check_system_basics netdev", []string{"include-interface-name=[eth0]"})
+	b := Key("check_system_basics netdev", []string{"include-interface-name=[eth1]"})
+
+	if a == b {
+		t.Fatalf("expected different keys for different arguments, got %v twice", a)
+	}
+
+	if a != Key("check_system_basics netdev", []string{"include-interface-name=[eth0]"}) {
+		t.Fatalf("expected stable keys for the same arguments")
+	}
+
+	if filepath.Base(a) != a {
+		t.Fatalf("expected key without path separators, got %v", a)
+	}
+}
+
+func TestRateAcrossRuns(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Unix(1700000000, 0)
+
+	first, err := open(dir, "test.json", start, 1000)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	_, err = first.Rate("bytes", 100)
+	if !errors.Is(err, ErrNoPreviousValue) {
+		t.Fatalf("expected %v, got %v", ErrNoPreviousValue, err)
+	}
+
+	first.SetString("active", "eth0")
+
+	err = first.Close()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	second, err := open(dir, "test.json", start.Add(10*time.Second), 1000)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	rate, err := second.Rate("bytes", 600)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if rate != 50 {
+		t.Fatalf("expected %v, got %v", 50, rate)
+	}
+
+	active, ok := second.PreviousString("active")
+	if !ok || active != "eth0" {
+		t.Fatalf("expected %v, got %v", "eth0", active)
+	}
+
+	_, err = second.Rate("bytes2", 600)
+	if !errors.Is(err, ErrNoPreviousValue) {
+		t.Fatalf("expected %v, got %v", ErrNoPreviousValue, err)
+	}
+
+	err = second.Close()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	third, err := open(dir, "test.json", start.Add(20*time.Second), 1000)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	_, err = third.Rate("bytes", 10)
+	if !errors.Is(err, ErrCounterReset) {
+		t.Fatalf("expected %v, got %v", ErrCounterReset, err)
+	}
+
+	third.unlock()
+}
+
+func TestReboot(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Unix(1700000000, 0)
+
+	first, err := open(dir, "test.json", start, 1000)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	_, _ = first.Delta("packets", 100)
+
+	err = first.Close()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	second, err := open(dir, "test.json", start.Add(time.Minute), 1050)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer second.unlock()
+
+	if !second.Rebooted || second.Previous != nil {
+		t.Fatalf("expected the previous state to be discarded after a reboot")
+	}
+}
+
+func TestBrokenStateFile(t *testing.T) {
+	dir := t.TempDir()
+
+	err := os.WriteFile(filepath.Join(dir, "test.json"), []byte("{broken"), 0o600)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	store, err := open(dir, "test.json", time.Now(), 1000)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer store.unlock()
+
+	if store.Previous != nil {
+		t.Fatalf("expected no previous state, got %v", store.Previous)
+	}
+}
+
+func TestSeriesAcrossReboots(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Unix(1700000000, 0)
+
+	for i, bootTime := range []uint64{1000, 1000, 5000} {
+		store, err := open(dir, "test.json", start.Add(time.Duration(i)*time.Hour), bootTime)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		samples := store.AddSample("used", float64(i), 90*time.Minute)
+
+		// The window only covers the previous and the current run
+		expected := min(i+1, 2)
+		if len(samples) != expected {
+			t.Fatalf("expected %v, got %v", expected, len(samples))
+		}
+
+		if samples[len(samples)-1].Value != float64(i) {
+			t.Fatalf("expected %v, got %v", float64(i), samples[len(samples)-1].Value)
+		}
+
+		err = store.Close()
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+}
+
+func TestKeepSeries(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Unix(1700000000, 0)
+
+	for i := range 3 {
+		store, err := open(dir, "test.json", start.Add(time.Duration(i)*time.Hour), 1000)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		// The value could not be read in the second run
+		if i == 1 {
+			store.KeepSeries("used", 24*time.Hour)
+		} else {
+			samples := store.AddSample("used", float64(i), 24*time.Hour)
+
+			expected := i/2 + 1
+			if len(samples) != expected {
+				t.Fatalf("expected %v, got %v", expected, len(samples))
+			}
+		}
+
+		err = store.Close()
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+}
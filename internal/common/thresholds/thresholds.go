@@ -1,6 +1,8 @@
 package thresholds
 
 import (
+	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/NETWAYS/go-check"
@@ -17,6 +19,39 @@ type Thresholds struct {
 	Crit ThresholdWrapper
 }
 
+// Evaluate returns the state of value with regard to the warning and critical threshold.
+// If pd is not nil, the thresholds which are set are added to the perfdata
+func (t *Thresholds) Evaluate(value float64, pd *check.Perfdata) check.Status {
+	state := check.OK
+
+	if t.Warn.IsSet {
+		if pd != nil {
+			pd.Warn = &t.Warn.Th
+		}
+
+		if t.Warn.Th.DoesViolate(value) {
+			state = check.Warning
+		}
+	}
+
+	if t.Crit.IsSet {
+		if pd != nil {
+			pd.Crit = &t.Crit.Th
+		}
+
+		if t.Crit.Th.DoesViolate(value) {
+			state = check.Critical
+		}
+	}
+
+	return state
+}
+
+// IsSet returns true if either the warning or the critical threshold is set
+func (t *Thresholds) IsSet() bool {
+	return t.Warn.IsSet || t.Crit.IsSet
+}
+
 func (t *ThresholdWrapper) Set(foo string) error {
 	tmp, err := check.ParseThreshold(foo)
 	if err != nil {
@@ -59,3 +94,81 @@ func AddFlags(flagP *pflag.FlagSet, ths *[]ThresholdOption) {
 		flagP.Var((*ths)[i].Th, (*ths)[i].FlagString, desc.String())
 	}
 }
+
+// NamedThresholdsType is the flag type of NamedThresholds
+const NamedThresholdsType = "Name=Range_Expression"
+
+// NamedThresholds is a flag value for thresholds on values selected by a name,
+// which may be repeated in the form of "name=range"
+type NamedThresholds map[string]ThresholdWrapper
+
+func (n *NamedThresholds) Set(value string) error {
+	name, spec, found := strings.Cut(value, "=")
+	if !found || strings.TrimSpace(name) == "" {
+		return fmt.Errorf("expected a threshold in the form of name=range, got %q", value)
+	}
+
+	tw := ThresholdWrapper{}
+
+	err := tw.Set(spec)
+	if err != nil {
+		return err
+	}
+
+	if *n == nil {
+		*n = make(NamedThresholds)
+	}
+
+	(*n)[strings.TrimSpace(name)] = tw
+
+	return nil
+}
+
+func (n *NamedThresholds) String() string {
+	names := Names(*n)
+	values := make([]string, len(names))
+
+	for i := range names {
+		tw := (*n)[names[i]]
+		values[i] = names[i] + "=" + tw.String()
+	}
+
+	return strings.Join(values, ",")
+}
+
+func (n *NamedThresholds) Type() string {
+	return NamedThresholdsType
+}
+
+// IsRepeatable reports that every occurrence of the flag adds a threshold
+func (n *NamedThresholds) IsRepeatable() bool {
+	return true
+}
+
+// Names returns the sorted union of the names of all given NamedThresholds
+func Names(named ...NamedThresholds) []string {
+	unique := make(map[string]bool)
+
+	for i := range named {
+		for name := range named[i] {
+			unique[name] = true
+		}
+	}
+
+	result := make([]string, 0, len(unique))
+	for name := range unique {
+		result = append(result, name)
+	}
+
+	sort.Strings(result)
+
+	return result
+}
+
+// Select combines the warning and critical thresholds for name
+func Select(warn, crit NamedThresholds, name string) Thresholds {
+	return Thresholds{
+		Warn: warn[name],
+		Crit: crit[name],
+	}
+}
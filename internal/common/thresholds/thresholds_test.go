@@ -25,3 +25,62 @@ func TestThresholdSet(t *testing.T) {
 		t.Fatalf("expected %v, got %v", tw, tw2)
 	}
 }
+
+func TestThresholdsEvaluate(t *testing.T) {
+	ths := Thresholds{}
+
+	pd := check.Perfdata{Label: "test", Value: 50}
+
+	if ths.Evaluate(50, &pd) != check.OK || pd.Warn != nil || pd.Crit != nil {
+		t.Fatalf("expected OK without thresholds in perfdata")
+	}
+
+	_ = ths.Warn.Set("40")
+	_ = ths.Crit.Set("60")
+
+	if ths.Evaluate(50, &pd) != check.Warning {
+		t.Fatalf("expected %v, got %v", check.Warning, ths.Evaluate(50, &pd))
+	}
+
+	if ths.Evaluate(70, nil) != check.Critical {
+		t.Fatalf("expected %v, got %v", check.Critical, ths.Evaluate(70, nil))
+	}
+
+	if pd.Warn == nil || pd.Crit == nil {
+		t.Fatalf("expected thresholds in perfdata")
+	}
+}
+
+func TestNamedThresholds(t *testing.T) {
+	warn := NamedThresholds{}
+	crit := NamedThresholds{}
+
+	err := warn.Set("rx_fifo_errors=10")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	err = crit.Set("multicast=@0:5")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	err = crit.Set("multicast")
+	if err == nil {
+		t.Fatalf("expected an error for a threshold without a name")
+	}
+
+	expected := []string{"multicast", "rx_fifo_errors"}
+	if !reflect.DeepEqual(expected, Names(warn, crit)) {
+		t.Fatalf("expected %v, got %v", expected, Names(warn, crit))
+	}
+
+	ths := Select(warn, crit, "rx_fifo_errors")
+	if !ths.Warn.IsSet || ths.Crit.IsSet {
+		t.Fatalf("expected only the warning threshold to be set, got %v", ths)
+	}
+
+	if warn.String() != "rx_fifo_errors=10" {
+		t.Fatalf("expected %v, got %v", "rx_fifo_errors=10", warn.String())
+	}
+}
@@ -0,0 +1,32 @@
+package directory
+
+import (
+	"time"
+
+	"github.com/NETWAYS/check_system_basics/internal/common/thresholds"
+)
+
+type CheckConfig struct {
+	Paths []string
+	// MaxDepth limits the levels below a path which are walked, a negative value means no limit
+	MaxDepth int
+	// WalkTimeout is the time the walk of a single path may take
+	WalkTimeout time.Duration
+
+	// Unit of the age thresholds
+	AgeUnit string
+
+	Size      thresholds.Thresholds
+	FileCount thresholds.Thresholds
+	OldestAge thresholds.Thresholds
+	NewestAge thresholds.Thresholds
+
+	Filters Filter
+}
+
+type Filter struct {
+	// Glob patterns for the names of files which are counted
+	Include []string
+	// Glob patterns for the names of files and directories which are skipped
+	Exclude []string
+}
@@ -0,0 +1,189 @@
+package directory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Units for the age thresholds
+const (
+	UnitSeconds = "seconds"
+	UnitMinutes = "minutes"
+	UnitHours   = "hours"
+	UnitDays    = "days"
+)
+
+// DefaultWalkTimeout is the time the walk of a single path may take if nothing else is configured
+const DefaultWalkTimeout = 10 * time.Second
+
+// errAborted stops the walk when the timeout is exceeded
+var errAborted = errors.New("walk aborted")
+
+// Stats are the collected statistics about the files below a path
+type Stats struct {
+	Path  string
+	Files uint64
+	// Size is the sum of the sizes of all counted files
+	Size uint64
+	// Oldest and Newest are the modification times of the oldest and the newest file, they are zero without files
+	Oldest time.Time
+	Newest time.Time
+	// Unreadable is the number of files and directories which could not be read
+	Unreadable uint64
+	// Complete is false if the walk was aborted due to the timeout
+	Complete bool
+}
+
+// UnitDuration returns the duration of a unit of the age thresholds
+func UnitDuration(unit string) (time.Duration, error) {
+	switch unit {
+	case UnitSeconds:
+		return time.Second, nil
+	case UnitMinutes:
+		return time.Minute, nil
+	case UnitHours:
+		return time.Hour, nil
+	case UnitDays:
+		return 24 * time.Hour, nil
+	default:
+		return 0, fmt.Errorf("unknown unit %q, expected one of %s", unit, strings.Join([]string{UnitSeconds, UnitMinutes, UnitHours, UnitDays}, ", "))
+	}
+}
+
+// ValidateFilter checks the glob patterns of the filter
+func ValidateFilter(filters *Filter) error {
+	for _, pattern := range append(append([]string{}, filters.Include...), filters.Exclude...) {
+		_, err := filepath.Match(pattern, "")
+		if err != nil {
+			return fmt.Errorf("invalid glob pattern %q: %w", pattern, err)
+		}
+	}
+
+	return nil
+}
+
+// matchesAny returns true if name matches one of the (validated) glob patterns
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if match, _ := filepath.Match(pattern, name); match {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Walk collects the statistics about the regular files below root, up to maxDepth levels deep
+// (unlimited if negative, 1 means only the files directly in root, like find -maxdepth). Symbolic links below root are not followed,
+// but root itself may be one. If ctx is done, the walk is aborted and the statistics are incomplete.
+// An error is only returned if root itself can not be read
+func Walk(ctx context.Context, root string, maxDepth int, filters *Filter) (Stats, error) {
+	stats := Stats{Path: root}
+
+	// WalkDir does not descend into a symbolic link, even if it is the root
+	root, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return stats, err
+	}
+
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return errAborted
+		}
+
+		if err != nil {
+			if path == root {
+				return err
+			}
+
+			stats.Unreadable++
+
+			if entry != nil && entry.IsDir() {
+				return fs.SkipDir
+			}
+
+			return nil
+		}
+
+		if path == root {
+			return nil
+		}
+
+		if matchesAny(filters.Exclude, entry.Name()) {
+			if entry.IsDir() {
+				return fs.SkipDir
+			}
+
+			return nil
+		}
+
+		depth := entryDepth(root, path)
+
+		if entry.IsDir() {
+			// The files in a directory are one level deeper than the directory
+			if maxDepth >= 0 && depth >= maxDepth {
+				return fs.SkipDir
+			}
+
+			return nil
+		}
+
+		if maxDepth >= 0 && depth > maxDepth {
+			return nil
+		}
+
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		if len(filters.Include) > 0 && !matchesAny(filters.Include, entry.Name()) {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			stats.Unreadable++
+			return nil
+		}
+
+		stats.Files++
+		stats.Size += uint64(info.Size())
+
+		if stats.Oldest.IsZero() || info.ModTime().Before(stats.Oldest) {
+			stats.Oldest = info.ModTime()
+		}
+
+		if info.ModTime().After(stats.Newest) {
+			stats.Newest = info.ModTime()
+		}
+
+		return nil
+	})
+
+	if errors.Is(err, errAborted) {
+		return stats, nil
+	}
+
+	if err != nil {
+		return stats, err
+	}
+
+	stats.Complete = true
+
+	return stats, nil
+}
+
+// entryDepth returns the level of path below root, the entries directly in root have a depth of 1
+func entryDepth(root, path string) int {
+	relative, err := filepath.Rel(root, path)
+	if err != nil {
+		return 0
+	}
+
+	return strings.Count(relative, string(filepath.Separator)) + 1
+}
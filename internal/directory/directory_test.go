@@ -0,0 +1,129 @@
+package directory
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func createFile(t *testing.T, path string, size int, modTime time.Time) {
+	t.Helper()
+
+	err := os.MkdirAll(filepath.Dir(path), 0o750)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	err = os.WriteFile(path, make([]byte, size), 0o600)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	err = os.Chtimes(path, modTime, modTime)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func testTree(t *testing.T) (string, time.Time) {
+	root := t.TempDir()
+	now := time.Unix(1700000000, 0)
+
+	createFile(t, filepath.Join(root, "a.log"), 100, now.Add(-time.Hour))
+	createFile(t, filepath.Join(root, "b.tmp"), 10, now.Add(-48*time.Hour))
+	createFile(t, filepath.Join(root, "sub", "c.log"), 200, now.Add(-2*time.Hour))
+	createFile(t, filepath.Join(root, "sub", "deeper", "d.log"), 300, now.Add(-3*time.Hour))
+	createFile(t, filepath.Join(root, "cache", "e.log"), 400, now)
+
+	return root, now
+}
+
+func TestWalk(t *testing.T) {
+	root, now := testTree(t)
+
+	stats, err := Walk(context.Background(), root, -1, &Filter{})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !stats.Complete || stats.Files != 5 || stats.Size != 1010 {
+		t.Fatalf("expected 5 files with 1010 bytes, got %+v", stats)
+	}
+
+	if !stats.Oldest.Equal(now.Add(-48*time.Hour)) || !stats.Newest.Equal(now) {
+		t.Fatalf("expected oldest %v and newest %v, got %v and %v", now.Add(-48*time.Hour), now, stats.Oldest, stats.Newest)
+	}
+}
+
+func TestWalkWithFilters(t *testing.T) {
+	root, now := testTree(t)
+
+	stats, err := Walk(context.Background(), root, 2, &Filter{Include: []string{"*.log"}, Exclude: []string{"cache"}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// a.log and sub/c.log, sub/deeper is too deep and cache is excluded
+	if stats.Files != 2 || stats.Size != 300 {
+		t.Fatalf("expected 2 files with 300 bytes, got %+v", stats)
+	}
+
+	if !stats.Oldest.Equal(now.Add(-2*time.Hour)) || !stats.Newest.Equal(now.Add(-time.Hour)) {
+		t.Fatalf("expected oldest %v and newest %v, got %v and %v", now.Add(-2*time.Hour), now.Add(-time.Hour), stats.Oldest, stats.Newest)
+	}
+
+	stats, err = Walk(context.Background(), root, 1, &Filter{})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if stats.Files != 2 {
+		t.Fatalf("expected %v, got %v", 2, stats.Files)
+	}
+}
+
+func TestWalkSymlinkedRoot(t *testing.T) {
+	root, _ := testTree(t)
+	link := filepath.Join(t.TempDir(), "link")
+
+	err := os.Symlink(root, link)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	stats, err := Walk(context.Background(), link, -1, &Filter{})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if stats.Path != link || stats.Files != 5 || stats.Size != 1010 {
+		t.Fatalf("expected 5 files with 1010 bytes in %v, got %+v", link, stats)
+	}
+}
+
+func TestWalkErrors(t *testing.T) {
+	_, err := Walk(context.Background(), filepath.Join(t.TempDir(), "missing"), -1, &Filter{})
+	if err == nil {
+		t.Fatal("expected an error for a missing path")
+	}
+
+	root, _ := testTree(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	stats, err := Walk(ctx, root, -1, &Filter{})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if stats.Complete {
+		t.Fatal("expected an incomplete walk after the timeout")
+	}
+
+	if ValidateFilter(&Filter{Include: []string{"[a-"}}) == nil {
+		t.Fatal("expected an error for an invalid glob pattern")
+	}
+}
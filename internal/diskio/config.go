@@ -0,0 +1,33 @@
+package diskio
+
+import (
+	"time"
+
+	"github.com/NETWAYS/check_system_basics/internal/common/thresholds"
+)
+
+type CheckConfig struct {
+	// SampleInterval is the time between two samples within one execution. If it is zero,
+	// the rates are computed against the previous execution instead
+	SampleInterval time.Duration
+
+	IncludePartitions bool
+
+	// Read and write operations per second
+	IOPS thresholds.Thresholds
+	// Read and written bytes per second
+	Throughput thresholds.Thresholds
+	// Average time of a read or write operation in milliseconds, including the time in the queue
+	Await thresholds.Thresholds
+	// Average number of operations in flight
+	QueueDepth thresholds.Thresholds
+	// Percentage of the time the device was busy
+	Utilization thresholds.Thresholds
+
+	Filters Filter
+}
+
+type Filter struct {
+	IncludeDeviceNames []string
+	ExcludeDeviceNames []string
+}
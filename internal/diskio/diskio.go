@@ -0,0 +1,329 @@
+package diskio
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NETWAYS/check_system_basics/internal/common/filter"
+	"github.com/NETWAYS/check_system_basics/internal/common/state"
+)
+
+const (
+	diskstatsPath = "/proc/diskstats"
+	sysPath       = "/sys"
+)
+
+// sectorSize is the unit of the sector counters in /proc/diskstats, independent of the device
+const sectorSize = 512
+
+// The counters of /proc/diskstats which are used, in the order of the file
+// (see Documentation/admin-guide/iostats.rst in the kernel)
+const (
+	Reads = iota
+	ReadsMerged
+	SectorsRead
+	ReadTime
+	Writes
+	WritesMerged
+	SectorsWritten
+	WriteTime
+	InFlight
+	IOTime
+	WeightedIOTime
+	counterLength
+)
+
+// counterNames are the names of the counters in the state
+var counterNames = [counterLength]string{
+	"reads",
+	"reads_merged",
+	"sectors_read",
+	"read_time",
+	"writes",
+	"writes_merged",
+	"sectors_written",
+	"write_time",
+	"in_flight",
+	"io_time",
+	"weighted_io_time",
+}
+
+// Counters are the values of one line of /proc/diskstats. Times are in milliseconds,
+// InFlight is the only value which is not a counter
+type Counters [counterLength]uint64
+
+// Device is a block device with its counters
+type Device struct {
+	Name      string
+	Partition bool
+	Counters  Counters
+}
+
+const (
+	DeviceName = iota
+)
+
+func (d Device) GetFilterableValue(ident uint) string {
+	switch ident {
+	case DeviceName:
+		return d.Name
+	default:
+		return ""
+	}
+}
+
+// GetAllDevices reads the counters of all block devices from /proc/diskstats
+func GetAllDevices() ([]Device, error) {
+	return getDevices(diskstatsPath, sysPath)
+}
+
+func getDevices(diskstatsPath, sysPath string) ([]Device, error) {
+	file, err := os.Open(diskstatsPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	devices := make([]Device, 0)
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		// major, minor and name precede the counters
+		if len(fields) < 3+counterLength {
+			return nil, fmt.Errorf("could not parse %s: expected at least %d fields, got %d", diskstatsPath, 3+counterLength, len(fields))
+		}
+
+		device := Device{Name: fields[2]}
+
+		for i := range device.Counters {
+			device.Counters[i], err = strconv.ParseUint(fields[3+i], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("could not parse %s of %s: %w", counterNames[i], device.Name, err)
+			}
+		}
+
+		// Slashes in device names (e.g. cciss/c0d0) are replaced by exclamation marks in sysfs
+		_, err = os.Stat(filepath.Join(sysPath, "class", "block", strings.ReplaceAll(device.Name, "/", "!"), "partition"))
+		device.Partition = err == nil
+
+		devices = append(devices, device)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return devices, nil
+}
+
+// FilterDevices applies the include and exclude filters and removes the partitions, unless they are included
+func FilterDevices(devices []Device, filters *Filter, includePartitions bool) ([]Device, error) {
+	result := make([]Device, 0, len(devices))
+
+	for i := range devices {
+		if includePartitions || !devices[i].Partition {
+			result = append(result, devices[i])
+		}
+	}
+
+	result, err := filter.Filter(result,
+		&filters.IncludeDeviceNames,
+		DeviceName,
+		filter.Options{
+			MatchIncludedInResult: true,
+			RegexpMatching:        true,
+			EmptyFilterNoMatch:    false,
+		},
+	)
+	if err != nil {
+		return []Device{}, err
+	}
+
+	result, err = filter.Filter(result,
+		&filters.ExcludeDeviceNames,
+		DeviceName,
+		filter.Options{
+			MatchIncludedInResult: false,
+			RegexpMatching:        true,
+			EmptyFilterNoMatch:    false,
+		},
+	)
+	if err != nil {
+		return []Device{}, err
+	}
+
+	return result, nil
+}
+
+// Rates are the averages of a device over a sampling period
+type Rates struct {
+	ReadsPerSecond  float64
+	WritesPerSecond float64
+	ReadBytes       float64
+	WrittenBytes    float64
+	// ReadAwait and WriteAwait are the average times of an operation in milliseconds
+	ReadAwait  float64
+	WriteAwait float64
+	// Await is the average time of all read and write operations
+	Await float64
+	// QueueDepth is the average number of operations in flight
+	QueueDepth float64
+	// Utilization is the percentage of the time in which at least one operation was in flight
+	Utilization float64
+}
+
+// IOPS returns the read and write operations per second
+func (r *Rates) IOPS() float64 {
+	return r.ReadsPerSecond + r.WritesPerSecond
+}
+
+// Throughput returns the read and written bytes per second
+func (r *Rates) Throughput() float64 {
+	return r.ReadBytes + r.WrittenBytes
+}
+
+// Sub returns the increase of the counters since previous. The number of operations
+// in flight is taken from c, as it is a gauge
+func (c *Counters) Sub(previous *Counters) (Counters, error) {
+	var delta Counters
+
+	for i := range c {
+		if i == InFlight {
+			delta[i] = c[i]
+			continue
+		}
+
+		if c[i] < previous[i] {
+			return delta, state.ErrCounterReset
+		}
+
+		delta[i] = c[i] - previous[i]
+	}
+
+	return delta, nil
+}
+
+// ComputeRates computes the rates from the increase of the counters over elapsed
+func ComputeRates(delta *Counters, elapsed time.Duration) Rates {
+	seconds := elapsed.Seconds()
+	milliseconds := float64(elapsed.Milliseconds())
+
+	if seconds <= 0 {
+		return Rates{}
+	}
+
+	rates := Rates{
+		ReadsPerSecond:  float64(delta[Reads]) / seconds,
+		WritesPerSecond: float64(delta[Writes]) / seconds,
+		ReadBytes:       float64(delta[SectorsRead]*sectorSize) / seconds,
+		WrittenBytes:    float64(delta[SectorsWritten]*sectorSize) / seconds,
+		ReadAwait:       average(delta[ReadTime], delta[Reads]),
+		WriteAwait:      average(delta[WriteTime], delta[Writes]),
+		Await:           average(delta[ReadTime]+delta[WriteTime], delta[Reads]+delta[Writes]),
+		QueueDepth:      float64(delta[WeightedIOTime]) / milliseconds,
+		Utilization:     float64(delta[IOTime]) / milliseconds * 100,
+	}
+
+	// The busy time is counted in jiffies, so it may slightly exceed the elapsed time
+	if rates.Utilization > 100 {
+		rates.Utilization = 100
+	}
+
+	return rates
+}
+
+func average(total, count uint64) float64 {
+	if count == 0 {
+		return 0
+	}
+
+	return float64(total) / float64(count)
+}
+
+// GetDeviceRates stores the counters of the device in the state and computes the
+// rates since the previous execution.
+// All the counters are stored, even if the rates can not be computed.
+func GetDeviceRates(store *state.Store, device *Device) (Rates, error) {
+	var (
+		delta    Counters
+		firstErr error
+	)
+
+	for i := range device.Counters {
+		if i == InFlight {
+			delta[i] = device.Counters[i]
+			continue
+		}
+
+		value, err := store.Delta(device.Name+"_"+counterNames[i], device.Counters[i])
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+
+		delta[i] = value
+	}
+
+	if firstErr != nil {
+		return Rates{}, firstErr
+	}
+
+	elapsed, err := store.Elapsed()
+	if err != nil {
+		return Rates{}, err
+	}
+
+	return ComputeRates(&delta, elapsed), nil
+}
+
+// SampleRates reads the counters twice with interval in between and computes the rates of the
+// devices which are in devices. Devices which disappeared or were reset in the meantime are omitted
+func SampleRates(devices []Device, interval time.Duration) (map[string]Rates, error) {
+	return sampleRates(devices, interval, GetAllDevices)
+}
+
+func sampleRates(devices []Device, interval time.Duration, getDevices func() ([]Device, error)) (map[string]Rates, error) {
+	start := time.Now()
+
+	time.Sleep(interval)
+
+	current, err := getDevices()
+	if err != nil {
+		return nil, err
+	}
+
+	elapsed := time.Since(start)
+
+	previous := make(map[string]*Counters, len(devices))
+	for i := range devices {
+		previous[devices[i].Name] = &devices[i].Counters
+	}
+
+	rates := make(map[string]Rates, len(devices))
+
+	for i := range current {
+		counters, ok := previous[current[i].Name]
+		if !ok {
+			continue
+		}
+
+		// A counter which was reset (e.g. a device replaced in the meantime) gives no rates
+		delta, err := current[i].Counters.Sub(counters)
+		if err != nil {
+			continue
+		}
+
+		rates[current[i].Name] = ComputeRates(&delta, elapsed)
+	}
+
+	return rates, nil
+}
@@ -0,0 +1,146 @@
+package diskio
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestGetDevices(t *testing.T) {
+	devices, err := getDevices("testdata/diskstats", "testdata/sys")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(devices) != 4 {
+		t.Fatalf("expected %v, got %v", 4, len(devices))
+	}
+
+	if devices[0].Name != "sda" || devices[0].Partition {
+		t.Fatalf("expected %v, got %v", "sda", devices[0])
+	}
+
+	if !devices[1].Partition {
+		t.Fatalf("expected %v to be a partition", devices[1].Name)
+	}
+
+	if devices[2].Counters[SectorsRead] != 40000000 || devices[2].Counters[WeightedIOTime] != 140000 {
+		t.Fatalf("expected %v, got %v", "40000000 and 140000", devices[2].Counters)
+	}
+}
+
+func TestGetDevicesBroken(t *testing.T) {
+	_, err := getDevices("testdata/missing", "testdata/sys")
+	if err == nil {
+		t.Fatalf("expected an error, got none")
+	}
+}
+
+func TestFilterDevices(t *testing.T) {
+	devices, err := getDevices("testdata/diskstats", "testdata/sys")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	filtered, err := FilterDevices(devices, &Filter{ExcludeDeviceNames: []string{"^loop"}}, false)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(filtered) != 2 || filtered[0].Name != "sda" || filtered[1].Name != "nvme0n1" {
+		t.Fatalf("expected %v, got %v", "sda and nvme0n1", filtered)
+	}
+
+	filtered, err = FilterDevices(devices, &Filter{IncludeDeviceNames: []string{"^sd"}}, true)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(filtered) != 2 || filtered[1].Name != "sda1" {
+		t.Fatalf("expected %v, got %v", "sda and sda1", filtered)
+	}
+}
+
+func TestComputeRates(t *testing.T) {
+	previous := Counters{}
+	previous[Reads] = 1000
+	previous[SectorsRead] = 8000
+	previous[ReadTime] = 2000
+	previous[Writes] = 500
+	previous[WriteTime] = 5000
+	previous[IOTime] = 1000
+	previous[WeightedIOTime] = 7000
+
+	current := previous
+	current[Reads] += 200
+	current[SectorsRead] += 20480
+	current[ReadTime] += 400
+	current[Writes] += 100
+	current[WriteTime] += 1100
+	current[InFlight] = 3
+	current[IOTime] += 5000
+	current[WeightedIOTime] += 15000
+
+	delta, err := current.Sub(&previous)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if delta[InFlight] != 3 {
+		t.Fatalf("expected %v, got %v", 3, delta[InFlight])
+	}
+
+	rates := ComputeRates(&delta, 10*time.Second)
+
+	for name, values := range map[string][2]float64{
+		"iops":        {rates.IOPS(), 30},
+		"read bytes":  {rates.ReadBytes, 1024 * 1024},
+		"read await":  {rates.ReadAwait, 2},
+		"write await": {rates.WriteAwait, 11},
+		"await":       {rates.Await, 5},
+		"queue depth": {rates.QueueDepth, 1.5},
+		"utilization": {rates.Utilization, 50},
+	} {
+		if math.Abs(values[0]-values[1]) > 0.0001 {
+			t.Fatalf("expected %v for %s, got %v", values[1], name, values[0])
+		}
+	}
+
+	_, err = previous.Sub(&current)
+	if err == nil {
+		t.Fatalf("expected an error, got none")
+	}
+}
+
+func TestSampleRates(t *testing.T) {
+	devices := []Device{
+		{Name: "sda"},
+		{Name: "sdb"},
+	}
+
+	devices[1].Counters[Reads] = 100
+
+	rates, err := sampleRates(devices, 0, func() ([]Device, error) {
+		current := []Device{
+			{Name: "sda"},
+			{Name: "sdb"},
+			{Name: "sdc"},
+		}
+
+		current[0].Counters[Reads] = 10
+
+		return current, nil
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// sdb was reset and sdc was not sampled before
+	if len(rates) != 1 {
+		t.Fatalf("expected %v, got %v", 1, len(rates))
+	}
+
+	if _, ok := rates["sda"]; !ok {
+		t.Fatalf("expected rates for %v, got %v", "sda", rates)
+	}
+}
@@ -0,0 +1,251 @@
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The block group types of btrfs
+const (
+	BtrfsData     = "data"
+	BtrfsMetadata = "metadata"
+	BtrfsSystem   = "system"
+)
+
+// sectorSize is the unit of the size of block devices in sysfs
+const sectorSize = 512
+
+// ErrBtrfsNotFound is returned if a btrfs filesystem could not be found in sysfs
+var ErrBtrfsNotFound = errors.New("btrfs filesystem not found in /sys/fs/btrfs")
+
+// BtrfsAllocation is the space allocated for one block group type (e.g. metadata) of a btrfs filesystem.
+// TotalBytes and BytesUsed are the logical sizes, DiskTotal and DiskUsed the raw sizes on the devices,
+// which differ by the profile (e.g. twice as much for DUP or RAID1)
+type BtrfsAllocation struct {
+	Type       string
+	TotalBytes uint64
+	BytesUsed  uint64
+	DiskTotal  uint64
+	DiskUsed   uint64
+}
+
+// Usable returns the logical size this block group type could grow to, that is the allocated size
+// plus the unallocated raw space divided by the ratio of the profile
+func (a *BtrfsAllocation) Usable(unallocated uint64) uint64 {
+	ratio := 1.0
+	if a.TotalBytes > 0 && a.DiskTotal > a.TotalBytes {
+		ratio = float64(a.DiskTotal) / float64(a.TotalBytes)
+	}
+
+	return a.TotalBytes + uint64(float64(unallocated)/ratio)
+}
+
+// UsedPercent returns the used logical size as percentage of the usable size
+func (a *BtrfsAllocation) UsedPercent(unallocated uint64) float64 {
+	usable := a.Usable(unallocated)
+	if usable == 0 {
+		return 0
+	}
+
+	return float64(a.BytesUsed) / float64(usable) * 100
+}
+
+// BtrfsDevice contains the error counters of a device of a btrfs filesystem
+type BtrfsDevice struct {
+	ID     string
+	Errors map[string]uint64
+}
+
+// TotalErrors returns the sum of all error counters of the device
+func (d *BtrfsDevice) TotalErrors() uint64 {
+	var total uint64
+
+	for _, count := range d.Errors {
+		total += count
+	}
+
+	return total
+}
+
+// BtrfsInfo describes the allocation of a btrfs filesystem and its devices
+type BtrfsInfo struct {
+	UUID string
+	// Allocations contains the data, metadata and system allocation (in this order)
+	Allocations []BtrfsAllocation
+	// Size is the raw size of all devices
+	Size uint64
+	// Unallocated is the raw space not allocated to any block group
+	Unallocated uint64
+	// Devices is empty on kernels without error counters in sysfs
+	Devices []BtrfsDevice
+}
+
+// GetBtrfsInfo reads the allocation and the device errors of a btrfs filesystem from /sys/fs/btrfs
+func GetBtrfsInfo(fs *FilesystemType) (*BtrfsInfo, error) {
+	return getBtrfsInfo("/sys", fs)
+}
+
+func getBtrfsInfo(sysPath string, fs *FilesystemType) (*BtrfsInfo, error) {
+	btrfsPath := filepath.Join(sysPath, "fs", "btrfs")
+
+	uuid, err := findBtrfsUUID(btrfsPath, sysfsDeviceName(fs.PartStats.Device))
+	if err != nil {
+		return nil, err
+	}
+
+	basePath := filepath.Join(btrfsPath, uuid)
+	info := BtrfsInfo{UUID: uuid}
+
+	var allocated uint64
+
+	for _, blockGroupType := range []string{BtrfsData, BtrfsMetadata, BtrfsSystem} {
+		allocation, err := readBtrfsAllocation(filepath.Join(basePath, "allocation", blockGroupType), blockGroupType)
+		if err != nil {
+			return nil, err
+		}
+
+		allocated += allocation.DiskTotal
+
+		info.Allocations = append(info.Allocations, allocation)
+	}
+
+	devices, err := os.ReadDir(filepath.Join(basePath, "devices"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, device := range devices {
+		sectors, err := readUintFile(filepath.Join(basePath, "devices", device.Name(), "size"))
+		if err != nil {
+			return nil, err
+		}
+
+		info.Size += sectors * sectorSize
+	}
+
+	if info.Size > allocated {
+		info.Unallocated = info.Size - allocated
+	}
+
+	info.Devices, err = readBtrfsDevices(filepath.Join(basePath, "devinfo"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// findBtrfsUUID returns the UUID of the btrfs filesystem which contains the device
+func findBtrfsUUID(btrfsPath, device string) (string, error) {
+	filesystems, err := os.ReadDir(btrfsPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", ErrBtrfsNotFound
+		}
+
+		return "", err
+	}
+
+	for _, entry := range filesystems {
+		_, err := os.Stat(filepath.Join(btrfsPath, entry.Name(), "devices", device))
+		if err == nil {
+			return entry.Name(), nil
+		}
+	}
+
+	return "", fmt.Errorf("%w for device %s", ErrBtrfsNotFound, device)
+}
+
+func readBtrfsAllocation(path, blockGroupType string) (BtrfsAllocation, error) {
+	allocation := BtrfsAllocation{Type: blockGroupType}
+
+	fields := map[string]*uint64{
+		"total_bytes": &allocation.TotalBytes,
+		"bytes_used":  &allocation.BytesUsed,
+		"disk_total":  &allocation.DiskTotal,
+		"disk_used":   &allocation.DiskUsed,
+	}
+
+	for name, field := range fields {
+		value, err := readUintFile(filepath.Join(path, name))
+		if err != nil {
+			return allocation, err
+		}
+
+		*field = value
+	}
+
+	return allocation, nil
+}
+
+// readBtrfsDevices reads the error counters from devinfo/<id>/error_stats, which is available since Linux 5.14
+func readBtrfsDevices(path string) ([]BtrfsDevice, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	devices := make([]BtrfsDevice, 0, len(entries))
+
+	for _, entry := range entries {
+		content, err := os.ReadFile(filepath.Join(path, entry.Name(), "error_stats"))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		device := BtrfsDevice{
+			ID:     entry.Name(),
+			Errors: make(map[string]uint64),
+		}
+
+		for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+			name, value, found := strings.Cut(line, " ")
+			if !found {
+				return nil, fmt.Errorf("could not parse error_stats of btrfs device %s: %q", entry.Name(), line)
+			}
+
+			device.Errors[name], err = strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("could not parse error_stats of btrfs device %s: %w", entry.Name(), err)
+			}
+		}
+
+		devices = append(devices, device)
+	}
+
+	sort.Slice(devices, func(i, j int) bool {
+		a, _ := strconv.Atoi(devices[i].ID)
+		b, _ := strconv.Atoi(devices[j].ID)
+
+		return a < b
+	})
+
+	return devices, nil
+}
+
+func readUintFile(path string) (uint64, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := strconv.ParseUint(strings.TrimSpace(string(content)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("could not parse %s: %w", path, err)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,76 @@
+package filesystem
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGetBtrfsInfo(t *testing.T) {
+	btrfs := testMount("/dev/sdc1", "/srv", "btrfs", "rw")
+
+	info, err := getBtrfsInfo("testdata/sys", &btrfs)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if info.UUID != "3f0b0c2e-7d4a-4c55-9a8e-1c2b3d4e5f60" {
+		t.Fatalf("expected %v, got %v", "3f0b0c2e-7d4a-4c55-9a8e-1c2b3d4e5f60", info.UUID)
+	}
+
+	if len(info.Allocations) != 3 || info.Allocations[1].Type != BtrfsMetadata {
+		t.Fatalf("expected data, metadata and system allocation, got %v", info.Allocations)
+	}
+
+	if info.Size != 107374182400 {
+		t.Fatalf("expected %v, got %v", 107374182400, info.Size)
+	}
+
+	if info.Unallocated != 49375346688 {
+		t.Fatalf("expected %v, got %v", 49375346688, info.Unallocated)
+	}
+
+	// Metadata uses the DUP profile, so only half of the unallocated space is usable
+	metadata := info.Allocations[1]
+	if usable := metadata.Usable(info.Unallocated); usable != 2147483648+24687673344 {
+		t.Fatalf("expected %v, got %v", 2147483648+24687673344, usable)
+	}
+
+	if len(info.Devices) != 1 || info.Devices[0].TotalErrors() != 3 || info.Devices[0].Errors["read_errs"] != 2 {
+		t.Fatalf("expected one device with 3 errors, got %v", info.Devices)
+	}
+
+	other := testMount("/dev/sdd1", "/mnt", "btrfs", "rw")
+
+	_, err = getBtrfsInfo("testdata/sys", &other)
+	if !errors.Is(err, ErrBtrfsNotFound) {
+		t.Fatalf("expected %v, got %v", ErrBtrfsNotFound, err)
+	}
+
+	_, err = getBtrfsInfo("testdata/missing", &btrfs)
+	if !errors.Is(err, ErrBtrfsNotFound) {
+		t.Fatalf("expected %v, got %v", ErrBtrfsNotFound, err)
+	}
+}
+
+func TestBtrfsConfigEnabled(t *testing.T) {
+	config := BtrfsConfig{}
+
+	if config.Enabled() {
+		t.Fatalf("expected %v, got %v", false, true)
+	}
+
+	err := config.MetadataUsage.Crit.Set("95")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !config.Enabled() {
+		t.Fatalf("expected %v, got %v", true, false)
+	}
+
+	config = BtrfsConfig{Check: true}
+
+	if !config.Enabled() {
+		t.Fatalf("expected %v, got %v", true, false)
+	}
+}
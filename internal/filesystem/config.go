@@ -1,7 +1,10 @@
 package filesystem
 
 import (
+	"time"
+
 	"github.com/NETWAYS/check_system_basics/internal/common/thresholds"
+	"github.com/NETWAYS/go-check"
 )
 
 type DualThresholdWrapper struct {
@@ -34,6 +37,21 @@ type Filters struct {
 	ExcludeOptions []string
 }
 
+type BtrfsConfig struct {
+	// Report the allocation even without thresholds
+	Check bool
+	// Percentage of the usable data and metadata space
+	DataUsage     thresholds.Thresholds
+	MetadataUsage thresholds.Thresholds
+	// Sum of the error counters of a device
+	DeviceErrors thresholds.Thresholds
+}
+
+// Enabled returns true if the allocation of btrfs filesystems should be read
+func (c *BtrfsConfig) Enabled() bool {
+	return c.Check || c.DataUsage.IsSet() || c.MetadataUsage.IsSet() || c.DeviceErrors.IsSet()
+}
+
 type CheckConfig struct {
 	// Thresholds
 	WarningAbsolutThreshold  Thresholds
@@ -42,18 +60,47 @@ type CheckConfig struct {
 	WarningPercentThreshold  Thresholds
 	CriticalPercentThreshold Thresholds
 
+	// Rules replacing the thresholds above for selected filesystems
+	ThresholdRules ThresholdRules
+
 	WarningTotalCountOfFs  thresholds.ThresholdWrapper
 	CriticalTotalCountOfFs thresholds.ThresholdWrapper
 
+	Forecast ForecastConfig
+
+	// Thresholds for the allocation and the device errors of btrfs filesystems
+	Btrfs BtrfsConfig
+
 	Filters Filters
 
 	ReadonlyOption  bool
 	ReadWriteOption bool
 
+	// Compare the mounted filesystems with the fstab
+	CheckFstab bool
+	FstabPath  string
+
+	// Alert filesystems mounted read-only which are configured read-write in the fstab or by mount path
+	CheckRoRemount       bool
+	ExpectedRWMountPaths []string
+
+	// Concurrent retrieval of the usage
+	Workers      int
+	MountTimeout time.Duration
+
+	// The state of filesystems which do not answer in time
+	HungStateOption string
+	HungState       check.Status
+
 	// Output Verbosity
 	Verbosity uint
 }
 
+// RoRemountEnabled returns true if read-only remounts should be detected
+func (c *CheckConfig) RoRemountEnabled() bool {
+	return c.CheckRoRemount || len(c.ExpectedRWMountPaths) > 0
+}
+
 func GetFilesystemsWithFixedNumberOfInodes() []string {
 	return []string{
 		"bfs",
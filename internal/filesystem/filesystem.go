@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"regexp"
+	"sync"
 	"time"
 
 	"github.com/shirou/gopsutil/v3/disk"
@@ -17,21 +18,48 @@ type FilesystemType struct {
 	Error      error
 }
 
+// Defaults for the concurrent retrieval of the usage
+const (
+	DefaultWorkers      = 8
+	DefaultMountTimeout = 5 * time.Second
+)
+
+var (
+	// ErrHung is the error of a filesystem which did not answer before the deadline
+	ErrHung = errors.New("no response before the deadline, maybe a hanging network filesystem")
+	// ErrNotQueried is the error of a filesystem which was not queried before the overall deadline
+	ErrNotQueried = errors.New("not queried before the overall deadline")
+)
+
+// diskUsage retrieves the usage of a mount point, it is replaced in tests
+var diskUsage = disk.Usage
+
+// IsHung returns true if the filesystem did not answer before the deadline
+func (fs *FilesystemType) IsHung() bool {
+	return errors.Is(fs.Error, ErrHung)
+}
+
 type tmpFileSystemWrapper struct {
 	usage disk.UsageStat
 	err   error
 }
 
+// GetDiskUsageSingle retrieves the usage of a single filesystem. If the filesystem does not
+// answer within timeout (or before ctx is done), its Error is set to ErrHung.
+// The query of a hung filesystem can not be aborted, it is left behind
 func GetDiskUsageSingle(ctx context.Context, timeout time.Duration, fs *FilesystemType) {
 	myCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	resChan := make(chan tmpFileSystemWrapper, 1)
 
+	usage := diskUsage
+	mountpoint := fs.PartStats.Mountpoint
+
 	go func() {
 		tmp := tmpFileSystemWrapper{}
 
-		usageStats, err := disk.Usage(fs.PartStats.Mountpoint)
+		usageStats, err := usage(mountpoint)
 		if err == nil {
 			tmp.usage = *usageStats
 		}
@@ -50,16 +78,55 @@ func GetDiskUsageSingle(ctx context.Context, timeout time.Duration, fs *Filesyst
 
 		fs.UsageStats = tmp.usage
 	case <-myCtx.Done():
-		err := errors.New("Timeout exceeded for fs " + fs.PartStats.Mountpoint + ". Maybe hanging network filesystem?")
-		fs.Error = err
+		fs.Error = ErrHung
 	}
 }
 
-func GetDiskUsage(ctx context.Context, timeout time.Duration, fsList []FilesystemType, _ *CheckConfig) error {
+// GetDiskUsage retrieves the usage of all filesystems concurrently with config.Workers workers.
+// Every filesystem gets config.MountTimeout to answer, all of them must answer within timeout.
+// Filesystems which were not queried before the overall deadline get ErrNotQueried as Error
+func GetDiskUsage(ctx context.Context, timeout time.Duration, fsList []FilesystemType, config *CheckConfig) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	workers := config.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+
+	mountTimeout := config.MountTimeout
+	if mountTimeout <= 0 {
+		mountTimeout = DefaultMountTimeout
+	}
+
+	jobs := make(chan *FilesystemType)
+
+	var wg sync.WaitGroup
+
+	for range min(workers, len(fsList)) {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for fs := range jobs {
+				if ctx.Err() != nil {
+					fs.Error = ErrNotQueried
+					continue
+				}
+
+				GetDiskUsageSingle(ctx, mountTimeout, fs)
+			}
+		}()
+	}
+
 	for index := range fsList {
-		GetDiskUsageSingle(ctx, timeout/time.Duration(len(fsList)), &fsList[index])
+		jobs <- &fsList[index]
 	}
 
+	close(jobs)
+	wg.Wait()
+
 	return nil
 }
 
@@ -1,8 +1,11 @@
 package filesystem
 
 import (
+	"context"
+	"errors"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/shirou/gopsutil/v3/disk"
 )
@@ -164,3 +167,90 @@ func TestFilterRegexFilterAll(t *testing.T) {
 		t.Fatalf("expected %v, got %v", result, []FilesystemType{})
 	}
 }
+
+func TestGetDiskUsageHung(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+
+	original := diskUsage
+	defer func() { diskUsage = original }()
+
+	diskUsage = func(path string) (*disk.UsageStat, error) {
+		if path == "/hung" {
+			<-block
+		}
+
+		return &disk.UsageStat{Path: path, Total: 100}, nil
+	}
+
+	fsList := make([]FilesystemType, 0)
+
+	for _, mountpoint := range []string{"/a", "/hung", "/b", "/hung", "/c", "/d"} {
+		fsList = append(fsList, FilesystemType{PartStats: disk.PartitionStat{Mountpoint: mountpoint}})
+	}
+
+	config := CheckConfig{
+		Workers:      2,
+		MountTimeout: 50 * time.Millisecond,
+	}
+
+	start := time.Now()
+
+	err := GetDiskUsage(context.Background(), time.Second, fsList, &config)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// Both hung filesystems are waited for concurrently
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("expected the hung filesystems not to block the others, took %v", elapsed)
+	}
+
+	for i := range fsList {
+		hung := fsList[i].PartStats.Mountpoint == "/hung"
+
+		if fsList[i].IsHung() != hung {
+			t.Fatalf("expected hung to be %v for %s, got %v", hung, fsList[i].PartStats.Mountpoint, fsList[i].Error)
+		}
+
+		if !hung && fsList[i].UsageStats.Path != fsList[i].PartStats.Mountpoint {
+			t.Fatalf("expected usage of %s, got %v", fsList[i].PartStats.Mountpoint, fsList[i].UsageStats)
+		}
+	}
+}
+
+func TestGetDiskUsageOverallDeadline(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+
+	original := diskUsage
+	defer func() { diskUsage = original }()
+
+	diskUsage = func(_ string) (*disk.UsageStat, error) {
+		<-block
+		return nil, errors.New("unblocked")
+	}
+
+	fsList := []FilesystemType{
+		{PartStats: disk.PartitionStat{Mountpoint: "/a"}},
+		{PartStats: disk.PartitionStat{Mountpoint: "/b"}},
+	}
+
+	config := CheckConfig{
+		Workers:      1,
+		MountTimeout: time.Second,
+	}
+
+	err := GetDiskUsage(context.Background(), 50*time.Millisecond, fsList, &config)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !fsList[0].IsHung() {
+		t.Fatalf("expected %v, got %v", ErrHung, fsList[0].Error)
+	}
+
+	if !errors.Is(fsList[1].Error, ErrNotQueried) {
+		t.Fatalf("expected %v, got %v", ErrNotQueried, fsList[1].Error)
+	}
+}
@@ -0,0 +1,85 @@
+package filesystem
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/NETWAYS/check_system_basics/internal/common/state"
+	"github.com/NETWAYS/check_system_basics/internal/common/thresholds"
+)
+
+// MinForecastSamples is the number of samples needed to compute a forecast
+const MinForecastSamples = 3
+
+// Units for the time to full thresholds
+const (
+	ForecastUnitHours = "hours"
+	ForecastUnitDays  = "days"
+)
+
+// ForecastConfig configures the forecast of the time until a filesystem is full
+type ForecastConfig struct {
+	// Window is the time span of the samples used for the forecast, the forecast is disabled if it is zero
+	Window time.Duration
+	// Unit of the thresholds, either ForecastUnitHours or ForecastUnitDays
+	Unit string
+
+	SpaceTimeToFull  thresholds.Thresholds
+	InodesTimeToFull thresholds.Thresholds
+}
+
+// Enabled returns true if samples should be recorded and a forecast computed
+func (f *ForecastConfig) Enabled() bool {
+	return f.Window > 0
+}
+
+// UnitSeconds returns the number of seconds of the configured unit
+func (f *ForecastConfig) UnitSeconds() (float64, error) {
+	switch f.Unit {
+	case ForecastUnitHours:
+		return time.Hour.Seconds(), nil
+	case ForecastUnitDays:
+		return (24 * time.Hour).Seconds(), nil
+	default:
+		return 0, fmt.Errorf("unknown forecast unit %q, expected %s or %s", f.Unit, ForecastUnitHours, ForecastUnitDays)
+	}
+}
+
+// GrowthRate returns the slope of the least squares regression line through the samples in units per second.
+// ok is false if there are less than MinForecastSamples samples or all were taken at the same time
+func GrowthRate(samples []state.Sample) (rate float64, ok bool) {
+	if len(samples) < MinForecastSamples {
+		return 0, false
+	}
+
+	n := float64(len(samples))
+
+	var sumX, sumY, sumXY, sumXX float64
+
+	for _, sample := range samples {
+		x := sample.Timestamp.Sub(samples[0].Timestamp).Seconds()
+
+		sumX += x
+		sumY += sample.Value
+		sumXY += x * sample.Value
+		sumXX += x * x
+	}
+
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0, false
+	}
+
+	return (n*sumXY - sumX*sumY) / denominator, true
+}
+
+// SecondsToFull returns the estimated number of seconds until used reaches total when growing by rate per second.
+// ok is false if the usage does not grow
+func SecondsToFull(used, total, rate float64) (seconds float64, ok bool) {
+	if rate <= 0 {
+		return math.Inf(1), false
+	}
+
+	return math.Max(total-used, 0) / rate, true
+}
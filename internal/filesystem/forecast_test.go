@@ -0,0 +1,74 @@
+package filesystem
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/NETWAYS/check_system_basics/internal/common/state"
+)
+
+func TestGrowthRate(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+
+	samples := []state.Sample{
+		{Timestamp: start, Value: 100},
+		{Timestamp: start.Add(10 * time.Second), Value: 230},
+		{Timestamp: start.Add(20 * time.Second), Value: 290},
+		{Timestamp: start.Add(30 * time.Second), Value: 400},
+	}
+
+	rate, ok := GrowthRate(samples)
+	if !ok {
+		t.Fatal("expected a growth rate")
+	}
+
+	if math.Abs(rate-9.6) > 1e-9 {
+		t.Fatalf("expected %v, got %v", 9.6, rate)
+	}
+
+	_, ok = GrowthRate(samples[:2])
+	if ok {
+		t.Fatal("expected no growth rate for two samples")
+	}
+
+	sameTime := []state.Sample{{Timestamp: start}, {Timestamp: start}, {Timestamp: start}}
+
+	_, ok = GrowthRate(sameTime)
+	if ok {
+		t.Fatal("expected no growth rate for samples taken at the same time")
+	}
+}
+
+func TestSecondsToFull(t *testing.T) {
+	seconds, ok := SecondsToFull(400, 1000, 2)
+	if !ok || seconds != 300 {
+		t.Fatalf("expected %v, got %v", 300, seconds)
+	}
+
+	_, ok = SecondsToFull(400, 1000, 0)
+	if ok {
+		t.Fatal("expected no time to full for a filesystem which does not grow")
+	}
+
+	_, ok = SecondsToFull(400, 1000, -1)
+	if ok {
+		t.Fatal("expected no time to full for a filesystem which shrinks")
+	}
+}
+
+func TestForecastUnitSeconds(t *testing.T) {
+	config := ForecastConfig{Unit: ForecastUnitDays}
+
+	seconds, err := config.UnitSeconds()
+	if err != nil || seconds != 86400 {
+		t.Fatalf("expected %v, got %v (%v)", 86400, seconds, err)
+	}
+
+	config.Unit = "weeks"
+
+	_, err = config.UnitSeconds()
+	if err == nil {
+		t.Fatal("expected an error for an unknown unit")
+	}
+}
@@ -0,0 +1,245 @@
+package filesystem
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// DefaultFstabPath is the location of the fstab if nothing else is configured
+const DefaultFstabPath = "/etc/fstab"
+
+// FstabEntry is a filesystem configured in the fstab
+type FstabEntry struct {
+	Device     string
+	Mountpoint string
+	Fstype     string
+	Options    []string
+}
+
+// HasOption returns true if the entry has the option (without a value)
+func (e *FstabEntry) HasOption(option string) bool {
+	return slices.Contains(e.Options, option)
+}
+
+// Expected returns true if the entry should be mounted after the boot, that is
+// if it is neither swap nor marked with noauto
+func (e *FstabEntry) Expected() bool {
+	return e.Fstype != "swap" && e.Mountpoint != "none" && !e.HasOption("noauto")
+}
+
+// ReadOnly returns true if the entry is configured to be mounted read-only
+func (e *FstabEntry) ReadOnly() bool {
+	return e.HasOption("ro")
+}
+
+// ReadFstab parses the fstab at path
+func ReadFstab(path string) ([]FstabEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read fstab: %w", err)
+	}
+	defer file.Close()
+
+	entries := make([]FstabEntry, 0)
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("could not parse line %d of %s: expected at least 3 fields, got %d", lineNumber, path, len(fields))
+		}
+
+		entry := FstabEntry{
+			Device:     unescapeFstab(fields[0]),
+			Mountpoint: unescapeFstab(fields[1]),
+			Fstype:     fields[2],
+			Options:    []string{"defaults"},
+		}
+
+		if entry.Mountpoint != "none" {
+			entry.Mountpoint = filepath.Clean(entry.Mountpoint)
+		}
+
+		if len(fields) > 3 {
+			entry.Options = strings.Split(fields[3], ",")
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read fstab: %w", err)
+	}
+
+	return entries, nil
+}
+
+// unescapeFstab replaces the octal escape sequences (e.g. \040 for a space) of fstab fields
+func unescapeFstab(field string) string {
+	if !strings.Contains(field, `\`) {
+		return field
+	}
+
+	result := strings.Builder{}
+
+	for i := 0; i < len(field); i++ {
+		if field[i] == '\\' && i+4 <= len(field) {
+			value, err := strconv.ParseUint(field[i+1:i+4], 8, 8)
+			if err == nil {
+				result.WriteByte(byte(value))
+
+				i += 3
+
+				continue
+			}
+		}
+
+		result.WriteByte(field[i])
+	}
+
+	return result.String()
+}
+
+// mountOptionConflicts contains the per mount options which can be compared with the options of a
+// mounted filesystem. They are mapped to the option shown instead if they are not in effect, or to an
+// empty string if they are simply missing then. The defaults suid, dev and exec are never shown.
+// Other options (e.g. of the filesystem itself or for the mount tool) are not part of the mount options.
+var mountOptionConflicts = map[string]string{
+	"ro":          "rw",
+	"rw":          "ro",
+	"suid":        "nosuid",
+	"dev":         "nodev",
+	"exec":        "noexec",
+	"nosuid":      "",
+	"nodev":       "",
+	"noexec":      "",
+	"noatime":     "",
+	"relatime":    "",
+	"strictatime": "",
+}
+
+// expectedMountOptions returns the comparable options of the entry
+func (e *FstabEntry) expectedMountOptions() []string {
+	options := make([]string, 0, len(e.Options)+1)
+
+	if !e.ReadOnly() {
+		options = append(options, "rw")
+	}
+
+	for _, option := range e.Options {
+		if _, ok := mountOptionConflicts[option]; ok && !slices.Contains(options, option) {
+			options = append(options, option)
+		}
+	}
+
+	return options
+}
+
+// FstabMismatch is a mounted filesystem which differs from its fstab entry
+type FstabMismatch struct {
+	Entry FstabEntry
+	Mount FilesystemType
+	// Differences describes every difference, e.g. "fstype xfs instead of ext4"
+	Differences []string
+}
+
+// FstabComparison is the result of comparing the fstab with the mounted filesystems
+type FstabComparison struct {
+	// NotMounted are the expected entries which are not mounted
+	NotMounted []FstabEntry
+	// Mismatches are the mounts whose fstype or options differ from the fstab
+	Mismatches []FstabMismatch
+	// Unexpected are mounts without an entry in the fstab
+	Unexpected []FilesystemType
+}
+
+// CompareFstab compares the entries of the fstab with the mounted filesystems.
+// Only the filesystems in candidates are reported as unexpected, so that filters
+// can exclude pseudo filesystems like proc or tmpfs
+func CompareFstab(entries []FstabEntry, mounted, candidates []FilesystemType) FstabComparison {
+	comparison := FstabComparison{}
+
+	configured := make(map[string]bool, len(entries))
+
+	for i := range entries {
+		configured[entries[i].Mountpoint] = true
+
+		if !entries[i].Expected() {
+			continue
+		}
+
+		// The last mount on a mount point hides the previous ones
+		mount := -1
+
+		for j := range mounted {
+			if filepath.Clean(mounted[j].PartStats.Mountpoint) == entries[i].Mountpoint {
+				mount = j
+			}
+		}
+
+		if mount < 0 {
+			comparison.NotMounted = append(comparison.NotMounted, entries[i])
+			continue
+		}
+
+		differences := compareFstabEntry(&entries[i], &mounted[mount])
+		if len(differences) > 0 {
+			comparison.Mismatches = append(comparison.Mismatches, FstabMismatch{
+				Entry:       entries[i],
+				Mount:       mounted[mount],
+				Differences: differences,
+			})
+		}
+	}
+
+	for i := range candidates {
+		if !configured[filepath.Clean(candidates[i].PartStats.Mountpoint)] {
+			comparison.Unexpected = append(comparison.Unexpected, candidates[i])
+		}
+	}
+
+	return comparison
+}
+
+func compareFstabEntry(entry *FstabEntry, mount *FilesystemType) []string {
+	differences := make([]string, 0)
+
+	if entry.Fstype != "auto" && !sameFstype(entry.Fstype, mount.PartStats.Fstype) {
+		differences = append(differences, fmt.Sprintf("fstype %s instead of %s", mount.PartStats.Fstype, entry.Fstype))
+	}
+
+	for _, option := range entry.expectedMountOptions() {
+		conflict := mountOptionConflicts[option]
+
+		switch {
+		case conflict != "" && slices.Contains(mount.PartStats.Opts, conflict):
+			differences = append(differences, fmt.Sprintf("option %s instead of %s", conflict, option))
+		case conflict == "" && !slices.Contains(mount.PartStats.Opts, option):
+			differences = append(differences, fmt.Sprintf("option %s missing", option))
+		}
+	}
+
+	return differences
+}
+
+// sameFstype compares filesystem types, the version of nfs is not configured consistently
+func sameFstype(configured, mounted string) bool {
+	if configured == mounted {
+		return true
+	}
+
+	return strings.HasPrefix(configured, "nfs") && strings.HasPrefix(mounted, "nfs")
+}
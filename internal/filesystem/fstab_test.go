@@ -0,0 +1,98 @@
+package filesystem
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+func TestReadFstab(t *testing.T) {
+	entries, err := ReadFstab("testdata/fstab")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(entries) != 7 {
+		t.Fatalf("expected %v, got %v", 7, len(entries))
+	}
+
+	expected := FstabEntry{
+		Device:     "nas:/export/backup",
+		Mountpoint: "/mnt/my backup",
+		Fstype:     "nfs4",
+		Options:    []string{"ro", "_netdev"},
+	}
+
+	if !reflect.DeepEqual(expected, entries[5]) {
+		t.Fatalf("expected %v, got %v", expected, entries[5])
+	}
+
+	if !reflect.DeepEqual([]string{"defaults"}, entries[6].Options) {
+		t.Fatalf("expected %v, got %v", []string{"defaults"}, entries[6].Options)
+	}
+
+	for i, expected := range []bool{true, true, true, false, false, true, true} {
+		if entries[i].Expected() != expected {
+			t.Fatalf("expected %v for %s, got %v", expected, entries[i].Mountpoint, entries[i].Expected())
+		}
+	}
+
+	_, err = ReadFstab("testdata/missing")
+	if err == nil {
+		t.Fatal("expected an error for a missing fstab")
+	}
+}
+
+func testMount(device, mountpoint, fstype string, opts ...string) FilesystemType {
+	return FilesystemType{
+		PartStats: disk.PartitionStat{
+			Device:     device,
+			Mountpoint: mountpoint,
+			Fstype:     fstype,
+			Opts:       opts,
+		},
+	}
+}
+
+func TestCompareFstab(t *testing.T) {
+	entries, err := ReadFstab("testdata/fstab")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	mounted := []FilesystemType{
+		testMount("/dev/sda2", "/", "ext4", "rw", "relatime"),
+		testMount("/dev/sda1", "/boot", "ext4", "rw", "nosuid", "relatime"),
+		testMount("/dev/mapper/vg-data", "/srv/data", "ext4", "ro", "relatime"),
+		testMount("tmpfs", "/tmp", "tmpfs", "rw", "nosuid", "nodev"),
+		testMount("proc", "/proc", "proc", "rw", "nosuid", "nodev", "noexec"),
+		testMount("/dev/sdb1", "/mnt/usb", "vfat", "rw"),
+	}
+
+	// Only /mnt/usb remains after the filters
+	comparison := CompareFstab(entries, mounted, mounted[5:])
+
+	if len(comparison.NotMounted) != 1 || comparison.NotMounted[0].Mountpoint != "/mnt/my backup" {
+		t.Fatalf("expected /mnt/my backup not to be mounted, got %v", comparison.NotMounted)
+	}
+
+	// The options of /tmp are not compared, as defaults does not say anything about nosuid and nodev
+	if len(comparison.Mismatches) != 2 {
+		t.Fatalf("expected %v, got %v", 2, comparison.Mismatches)
+	}
+
+	expected := []string{"option nodev missing"}
+	if !reflect.DeepEqual(expected, comparison.Mismatches[0].Differences) {
+		t.Fatalf("expected %v, got %v", expected, comparison.Mismatches[0].Differences)
+	}
+
+	expected = []string{"fstype ext4 instead of xfs", "option ro instead of rw", "option noatime missing"}
+	if !reflect.DeepEqual(expected, comparison.Mismatches[1].Differences) {
+		t.Fatalf("expected %v, got %v", expected, comparison.Mismatches[1].Differences)
+	}
+
+	if len(comparison.Unexpected) != 1 || comparison.Unexpected[0].PartStats.Mountpoint != "/mnt/usb" {
+		t.Fatalf("expected /mnt/usb to be unexpected, got %v", comparison.Unexpected)
+	}
+}
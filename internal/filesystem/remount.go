@@ -0,0 +1,113 @@
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// KernelError is a counter or setting about errors exported by a filesystem driver in sysfs
+type KernelError struct {
+	// Name is the path of the entry below the sysfs directory of the filesystem, e.g. "errors_count"
+	Name  string
+	Value int64
+}
+
+// kernelErrorEntries are the entries read per filesystem type below /sys/fs/<type>/<device>
+var kernelErrorEntries = map[string][]string{
+	"ext4": {
+		"errors_count",
+		"first_error_time",
+		"last_error_time",
+	},
+	"xfs": {
+		"error/fail_at_unmount",
+		"error/metadata/default/max_retries",
+		"error/metadata/EIO/max_retries",
+		"error/metadata/ENOSPC/max_retries",
+		"error/metadata/ENODEV/max_retries",
+	},
+}
+
+// IsReadOnly returns true if the filesystem is mounted read-only
+func (fs *FilesystemType) IsReadOnly() bool {
+	return slices.Contains(fs.PartStats.Opts, "ro")
+}
+
+// ExpectsReadWrite returns true if the filesystem is configured read-write, either by an fstab entry
+// which is mounted at boot or by a mount path matching one of the regular expressions in mountPaths
+func ExpectsReadWrite(fs *FilesystemType, entries []FstabEntry, mountPaths []string) (bool, error) {
+	for _, mountPath := range mountPaths {
+		match, err := regexp.MatchString(mountPath, fs.PartStats.Mountpoint)
+		if err != nil {
+			return false, err
+		}
+
+		if match {
+			return true, nil
+		}
+	}
+
+	mountpoint := filepath.Clean(fs.PartStats.Mountpoint)
+
+	for i := range entries {
+		if entries[i].Mountpoint == mountpoint && entries[i].Expected() && !entries[i].ReadOnly() {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// GetKernelErrors reads the error counters of ext4 and the error settings of xfs filesystems from sysfs.
+// The result is empty for other filesystem types
+func GetKernelErrors(fs *FilesystemType) ([]KernelError, error) {
+	return getKernelErrors("/sys", fs)
+}
+
+func getKernelErrors(sysPath string, fs *FilesystemType) ([]KernelError, error) {
+	entries, ok := kernelErrorEntries[fs.PartStats.Fstype]
+	if !ok {
+		return nil, nil
+	}
+
+	basePath := filepath.Join(sysPath, "fs", fs.PartStats.Fstype, sysfsDeviceName(fs.PartStats.Device))
+
+	result := make([]KernelError, 0, len(entries))
+
+	for _, entry := range entries {
+		content, err := os.ReadFile(filepath.Join(basePath, entry))
+		if err != nil {
+			// Older kernels do not know all entries
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		value, err := strconv.ParseInt(strings.TrimSpace(string(content)), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse %s: %w", filepath.Join(basePath, entry), err)
+		}
+
+		result = append(result, KernelError{Name: entry, Value: value})
+	}
+
+	return result, nil
+}
+
+// sysfsDeviceName returns the name of the device as used in /sys/fs, e.g. dm-0 for /dev/mapper/vg-root
+func sysfsDeviceName(device string) string {
+	resolved, err := filepath.EvalSymlinks(device)
+	if err != nil {
+		return filepath.Base(device)
+	}
+
+	return filepath.Base(resolved)
+}
@@ -0,0 +1,77 @@
+package filesystem
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExpectsReadWrite(t *testing.T) {
+	entries, err := ReadFstab("testdata/fstab")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for _, test := range []struct {
+		mount      FilesystemType
+		mountPaths []string
+		expected   bool
+	}{
+		{testMount("/dev/sda2", "/", "ext4", "ro"), nil, true},
+		{testMount("nas:/export/backup", "/mnt/my backup", "nfs4", "ro"), nil, false},
+		{testMount("/dev/sdb1", "/mnt/usb", "vfat", "ro"), nil, false},
+		{testMount("/dev/sdb1", "/mnt/usb", "vfat", "ro"), []string{"^/mnt/"}, true},
+	} {
+		expectsRW, err := ExpectsReadWrite(&test.mount, entries, test.mountPaths)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if expectsRW != test.expected {
+			t.Fatalf("expected %v for %s, got %v", test.expected, test.mount.PartStats.Mountpoint, expectsRW)
+		}
+	}
+
+	mount := testMount("/dev/sdb1", "/mnt/usb", "vfat", "ro")
+
+	_, err = ExpectsReadWrite(&mount, entries, []string{"("})
+	if err == nil {
+		t.Fatal("expected an error for an invalid regex")
+	}
+}
+
+func TestGetKernelErrors(t *testing.T) {
+	ext4 := testMount("/dev/sda1", "/", "ext4", "ro")
+
+	kernelErrors, err := getKernelErrors("testdata/sys", &ext4)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := []KernelError{
+		{Name: "errors_count", Value: 3},
+		{Name: "first_error_time", Value: 1700000000},
+		{Name: "last_error_time", Value: 1700003600},
+	}
+
+	if !reflect.DeepEqual(expected, kernelErrors) {
+		t.Fatalf("expected %v, got %v", expected, kernelErrors)
+	}
+
+	xfs := testMount("/dev/sdb1", "/srv", "xfs", "rw")
+
+	kernelErrors, err = getKernelErrors("testdata/sys", &xfs)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(kernelErrors) != 5 || kernelErrors[0].Value != 1 || kernelErrors[4].Value != 0 || kernelErrors[1].Value != -1 {
+		t.Fatalf("expected the xfs error settings, got %v", kernelErrors)
+	}
+
+	vfat := testMount("/dev/sdc1", "/mnt/usb", "vfat", "rw")
+
+	kernelErrors, err = getKernelErrors("testdata/sys", &vfat)
+	if err != nil || len(kernelErrors) != 0 {
+		t.Fatalf("expected no kernel errors, got %v (%v)", kernelErrors, err)
+	}
+}
@@ -0,0 +1,229 @@
+package filesystem
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/NETWAYS/check_system_basics/internal/common/thresholds"
+)
+
+// ThresholdRulesType is the flag type of ThresholdRules
+const ThresholdRulesType = "Rule"
+
+// Selector keys of a rule
+const (
+	ruleKeyName       = "name"
+	ruleKeyMountPath  = "mount-path"
+	ruleKeyDevicePath = "device-path"
+	ruleKeyFsType     = "fs-type"
+)
+
+// Weights of the selectors to determine the most specific rule, a mount path
+// is more specific than a device, which is more specific than a filesystem type
+const (
+	weightMountPath  = 4
+	weightDevicePath = 2
+	weightFsType     = 1
+)
+
+// ThresholdRule replaces the thresholds for all filesystems matching every given expression
+type ThresholdRule struct {
+	Name string
+
+	MountPath  *regexp.Regexp
+	DevicePath *regexp.Regexp
+	FsType     *regexp.Regexp
+
+	WarningAbsolutThreshold  Thresholds
+	CriticalAbsolutThreshold Thresholds
+
+	WarningPercentThreshold  Thresholds
+	CriticalPercentThreshold Thresholds
+}
+
+// thresholdByName returns the threshold of the rule which corresponds to the flag with the given name
+func (r *ThresholdRule) thresholdByName(name string) *thresholds.ThresholdWrapper {
+	byName := map[string]*thresholds.ThresholdWrapper{
+		"warningAbsolutFreeSpace":   &r.WarningAbsolutThreshold.Space.Free,
+		"criticalAbsolutFreeSpace":  &r.CriticalAbsolutThreshold.Space.Free,
+		"warningAbsolutUsedSpace":   &r.WarningAbsolutThreshold.Space.Used,
+		"criticalAbsolutUsedSpace":  &r.CriticalAbsolutThreshold.Space.Used,
+		"warningPercentFreeSpace":   &r.WarningPercentThreshold.Space.Free,
+		"criticalPercentFreeSpace":  &r.CriticalPercentThreshold.Space.Free,
+		"warningPercentUsedSpace":   &r.WarningPercentThreshold.Space.Used,
+		"criticalPercentUsedSpace":  &r.CriticalPercentThreshold.Space.Used,
+		"warningAbsolutFreeInodes":  &r.WarningAbsolutThreshold.Inodes.Free,
+		"criticalAbsolutFreeInodes": &r.CriticalAbsolutThreshold.Inodes.Free,
+		"warningAbsolutUsedInodes":  &r.WarningAbsolutThreshold.Inodes.Used,
+		"criticalAbsolutUsedInodes": &r.CriticalAbsolutThreshold.Inodes.Used,
+		"warningPercentFreeInodes":  &r.WarningPercentThreshold.Inodes.Free,
+		"criticalPercentFreeInodes": &r.CriticalPercentThreshold.Inodes.Free,
+		"warningPercentUsedInodes":  &r.WarningPercentThreshold.Inodes.Used,
+		"criticalPercentUsedInodes": &r.CriticalPercentThreshold.Inodes.Used,
+	}
+
+	return byName[name]
+}
+
+// specificity returns how specific the rule is for the filesystem or -1 if it does not match
+func (r *ThresholdRule) specificity(fs *FilesystemType) int {
+	result := 0
+
+	if r.MountPath != nil {
+		if !r.MountPath.MatchString(fs.PartStats.Mountpoint) {
+			return -1
+		}
+
+		result += weightMountPath
+	}
+
+	if r.DevicePath != nil {
+		if !r.DevicePath.MatchString(fs.PartStats.Device) {
+			return -1
+		}
+
+		result += weightDevicePath
+	}
+
+	if r.FsType != nil {
+		if !r.FsType.MatchString(fs.PartStats.Fstype) {
+			return -1
+		}
+
+		result += weightFsType
+	}
+
+	return result
+}
+
+// ThresholdRules is a flag value for rules, which may be repeated with one rule per occurrence in the form of
+// "mount-path=^/data$ warningPercentUsedSpace=95 criticalPercentUsedSpace=98". The key=value pairs are separated
+// by whitespace, so the regular expressions may contain commas, e.g. "^/data{1,3}$"
+type ThresholdRules []ThresholdRule
+
+func (rules *ThresholdRules) Set(value string) error {
+	rule := ThresholdRule{}
+
+	for _, part := range strings.Fields(value) {
+		key, spec, found := strings.Cut(part, "=")
+		if !found {
+			return fmt.Errorf("expected key=value in rule %q, got %q", value, part)
+		}
+
+		key = strings.TrimSpace(key)
+
+		var err error
+
+		switch key {
+		case ruleKeyName:
+			rule.Name = spec
+		case ruleKeyMountPath:
+			rule.MountPath, err = regexp.Compile(spec)
+		case ruleKeyDevicePath:
+			rule.DevicePath, err = regexp.Compile(spec)
+		case ruleKeyFsType:
+			rule.FsType, err = regexp.Compile(spec)
+		default:
+			tw := rule.thresholdByName(key)
+			if tw == nil {
+				return fmt.Errorf("unknown key %q in rule %q", key, value)
+			}
+
+			err = tw.Set(spec)
+		}
+
+		if err != nil {
+			return fmt.Errorf("invalid value for %q in rule %q: %w", key, value, err)
+		}
+	}
+
+	if rule.MountPath == nil && rule.DevicePath == nil && rule.FsType == nil {
+		return fmt.Errorf("rule %q does not select any filesystem, expected at least one of %s, %s or %s",
+			value, ruleKeyMountPath, ruleKeyDevicePath, ruleKeyFsType)
+	}
+
+	if rule.Name == "" {
+		rule.Name = rule.selectors()
+	}
+
+	*rules = append(*rules, rule)
+
+	return nil
+}
+
+// selectors describes the expressions of the rule
+func (r *ThresholdRule) selectors() string {
+	result := make([]string, 0, 3)
+
+	if r.MountPath != nil {
+		result = append(result, ruleKeyMountPath+"="+r.MountPath.String())
+	}
+
+	if r.DevicePath != nil {
+		result = append(result, ruleKeyDevicePath+"="+r.DevicePath.String())
+	}
+
+	if r.FsType != nil {
+		result = append(result, ruleKeyFsType+"="+r.FsType.String())
+	}
+
+	return strings.Join(result, " ")
+}
+
+func (rules *ThresholdRules) String() string {
+	names := make([]string, len(*rules))
+
+	for i := range *rules {
+		names[i] = (*rules)[i].Name
+	}
+
+	return strings.Join(names, ";")
+}
+
+func (rules *ThresholdRules) Type() string {
+	return ThresholdRulesType
+}
+
+// IsRepeatable reports that every occurrence of the flag adds a rule
+func (rules *ThresholdRules) IsRepeatable() bool {
+	return true
+}
+
+// Match returns the most specific rule for the filesystem or nil if none matches.
+// If several rules are equally specific, the last one given wins
+func (rules ThresholdRules) Match(fs *FilesystemType) *ThresholdRule {
+	var (
+		best            *ThresholdRule
+		bestSpecificity = -1
+	)
+
+	for i := range rules {
+		specificity := rules[i].specificity(fs)
+		if specificity >= 0 && specificity >= bestSpecificity {
+			best = &rules[i]
+			bestSpecificity = specificity
+		}
+	}
+
+	return best
+}
+
+// ForFilesystem returns the configuration to use for the filesystem and the name of the
+// applied rule (empty if no rule matches). The thresholds of a matching rule replace all
+// the thresholds of the configuration, thresholds not given in the rule are not evaluated
+func (c *CheckConfig) ForFilesystem(fs *FilesystemType) (*CheckConfig, string) {
+	rule := c.ThresholdRules.Match(fs)
+	if rule == nil {
+		return c, ""
+	}
+
+	result := *c
+
+	result.WarningAbsolutThreshold = rule.WarningAbsolutThreshold
+	result.CriticalAbsolutThreshold = rule.CriticalAbsolutThreshold
+	result.WarningPercentThreshold = rule.WarningPercentThreshold
+	result.CriticalPercentThreshold = rule.CriticalPercentThreshold
+
+	return &result, rule.Name
+}
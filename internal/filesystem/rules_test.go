@@ -0,0 +1,133 @@
+package filesystem
+
+import (
+	"testing"
+)
+
+func TestThresholdRulesSet(t *testing.T) {
+	var rules ThresholdRules
+
+	err := rules.Set("name=data mount-path=^/data$ warningPercentUsedSpace=95 criticalPercentUsedSpace=98")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	err = rules.Set("fs-type=^ext4$ criticalPercentUsedInodes=90")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(rules) != 2 {
+		t.Fatalf("expected %v, got %v", 2, len(rules))
+	}
+
+	if rules[0].Name != "data" {
+		t.Fatalf("expected %v, got %v", "data", rules[0].Name)
+	}
+
+	if !rules[0].WarningPercentThreshold.Space.Used.IsSet || rules[0].WarningPercentThreshold.Space.Used.Th.Upper != 95 {
+		t.Fatalf("expected warning threshold 95, got %v", rules[0].WarningPercentThreshold.Space.Used)
+	}
+
+	if rules[0].WarningPercentThreshold.Space.Free.IsSet {
+		t.Fatalf("expected no warning threshold for free space, got %v", rules[0].WarningPercentThreshold.Space.Free)
+	}
+
+	if rules[1].Name != "fs-type=^ext4$" {
+		t.Fatalf("expected %v, got %v", "fs-type=^ext4$", rules[1].Name)
+	}
+
+	// Commas belong to the regular expression
+	err = rules.Set("mount-path=^/data{1,3}$ criticalPercentUsedSpace=90")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if rules[2].MountPath.String() != "^/data{1,3}$" {
+		t.Fatalf("expected %v, got %v", "^/data{1,3}$", rules[2].MountPath)
+	}
+
+	for _, invalid := range []string{
+		"warningPercentUsedSpace=95",
+		"mount-path=^/data$ unknown=1",
+		"mount-path=^/data$ warningPercentUsedSpace=foo",
+		"mount-path=(",
+		"mount-path",
+	} {
+		if rules.Set(invalid) == nil {
+			t.Fatalf("expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestThresholdRulesMatch(t *testing.T) {
+	var rules ThresholdRules
+
+	for _, rule := range []string{
+		"name=ext4 fs-type=^ext4$ criticalPercentUsedSpace=80",
+		"name=root mount-path=^/$ criticalPercentUsedSpace=90",
+		"name=sda device-path=^/dev/sda criticalPercentUsedSpace=85",
+		"name=other mount-path=^/other$ criticalPercentUsedSpace=99",
+	} {
+		err := rules.Set(rule)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	rule := rules.Match(&testSda)
+	if rule == nil || rule.Name != "root" {
+		t.Fatalf("expected rule %v, got %v", "root", rule)
+	}
+
+	if rule = rules.Match(&testFsHalfFull); rule != nil {
+		t.Fatalf("expected no rule, got %v", rule.Name)
+	}
+
+	// A rule with more expressions is more specific
+	err := rules.Set("name=root-ext4 mount-path=^/$ fs-type=^ext4$ criticalPercentUsedSpace=95")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	rule = rules.Match(&testSda)
+	if rule == nil || rule.Name != "root-ext4" {
+		t.Fatalf("expected rule %v, got %v", "root-ext4", rule)
+	}
+}
+
+func TestCheckConfigForFilesystem(t *testing.T) {
+	config := CheckConfig{}
+
+	err := config.WarningPercentThreshold.Space.Free.Set("5:")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	result, rule := config.ForFilesystem(&testSda)
+	if result != &config || rule != "" {
+		t.Fatalf("expected the unchanged config, got rule %q", rule)
+	}
+
+	err = config.ThresholdRules.Set("name=root mount-path=^/$ warningPercentUsedSpace=95")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	result, rule = config.ForFilesystem(&testSda)
+	if rule != "root" {
+		t.Fatalf("expected %v, got %v", "root", rule)
+	}
+
+	if result.WarningPercentThreshold.Space.Free.IsSet {
+		t.Fatalf("expected the threshold for free space to be replaced, got %v", result.WarningPercentThreshold.Space.Free)
+	}
+
+	if !result.WarningPercentThreshold.Space.Used.IsSet {
+		t.Fatal("expected the threshold for used space of the rule")
+	}
+
+	if !config.WarningPercentThreshold.Space.Free.IsSet {
+		t.Fatal("expected the original config to be unchanged")
+	}
+}
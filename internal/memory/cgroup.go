@@ -0,0 +1,252 @@
+package memory
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/mem"
+)
+
+const selfCgroupPath = "/proc/self/cgroup"
+
+// Cgroup is the memory usage and the limits of a cgroup v2
+type Cgroup struct {
+	// Path is the path below the CgroupRoot
+	Path string
+
+	Current uint64
+	// Max is the hard limit and High the limit above which the cgroup is throttled, nil means unlimited
+	Max  *uint64
+	High *uint64
+
+	// Anon, File and InactiveFile are from memory.stat
+	Anon         uint64
+	File         uint64
+	InactiveFile uint64
+
+	// SwapAccounting is false if the swap files do not exist, e.g. if swap accounting is disabled
+	SwapAccounting bool
+	SwapCurrent    uint64
+	// SwapMax is nil if the swap is unlimited
+	SwapMax *uint64
+}
+
+// Limited returns true if the memory of the cgroup is limited by memory.max or memory.high
+func (c *Cgroup) Limited() bool {
+	return c.Max != nil || c.High != nil
+}
+
+// Limit returns the memory the cgroup may use, that is memory.max or memory.high if only that is set,
+// but at most hostTotal
+func (c *Cgroup) Limit(hostTotal uint64) uint64 {
+	limit := hostTotal
+
+	switch {
+	case c.Max != nil:
+		limit = min(limit, *c.Max)
+	case c.High != nil:
+		limit = min(limit, *c.High)
+	}
+
+	return limit
+}
+
+// Mem converts the cgroup to the statistics of the host, so the same thresholds can be evaluated.
+// The size is the Limit, the used memory is memory.current and the available memory is the size
+// minus the working set, which is memory.current without the inactive file cache (like the kubelet does)
+func (c *Cgroup) Mem(host *Mem) *Mem {
+	total := c.Limit(host.VirtMem.Total)
+
+	workingSet := c.Current
+	if workingSet > c.InactiveFile {
+		workingSet -= c.InactiveFile
+	} else {
+		workingSet = 0
+	}
+
+	virtMem := mem.VirtualMemoryStat{
+		Total:     total,
+		Used:      min(c.Current, total),
+		Available: total - min(workingSet, total),
+		Free:      total - min(c.Current, total),
+	}
+
+	if total > 0 {
+		virtMem.UsedPercent = float64(virtMem.Used) / float64(total) * 100
+	}
+
+	swapInfo := *host.SwapInfo
+
+	if c.SwapAccounting {
+		swapTotal := host.SwapInfo.Total
+		if c.SwapMax != nil {
+			swapTotal = min(swapTotal, *c.SwapMax)
+		}
+
+		swapInfo = mem.SwapMemoryStat{
+			Total: swapTotal,
+			Used:  min(c.SwapCurrent, swapTotal),
+			Free:  swapTotal - min(c.SwapCurrent, swapTotal),
+		}
+
+		if swapTotal > 0 {
+			swapInfo.UsedPercent = float64(swapInfo.Used) / float64(swapTotal) * 100
+		}
+	}
+
+	virtMem.SwapTotal = swapInfo.Total
+	virtMem.SwapFree = swapInfo.Free
+
+	result := Mem{
+		VirtMem:  &virtMem,
+		SwapInfo: &swapInfo,
+		Meminfo:  host.Meminfo,
+	}
+
+	if total > 0 {
+		result.MemAvailablePercentage = float64(virtMem.Available) / float64(total) * 100
+	}
+
+	return &result
+}
+
+// DetectCgroup returns the cgroup v2 of the process if it runs in a container with a memory limit,
+// otherwise nil. A container is recognised by the process being in the root of its cgroup namespace
+// while memory.max exists there, which is not the case for the root of the host. A memory limit of
+// a systemd service running the check is deliberately not used
+func DetectCgroup() (*Cgroup, error) {
+	return detectCgroup(selfCgroupPath, CgroupRoot)
+}
+
+func detectCgroup(selfCgroupPath, root string) (*Cgroup, error) {
+	path, err := unifiedCgroupPath(selfCgroupPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if path != "/" {
+		return nil, nil
+	}
+
+	_, err = os.Stat(filepath.Join(root, "memory.max"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	cgroup, err := readCgroup(root, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !cgroup.Limited() {
+		return nil, nil
+	}
+
+	return cgroup, nil
+}
+
+// unifiedCgroupPath returns the path of the cgroup v2 of the process or an empty string if there is none
+func unifiedCgroupPath(selfCgroupPath string) (string, error) {
+	file, err := os.Open(selfCgroupPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		if path, found := strings.CutPrefix(scanner.Text(), "0::"); found {
+			return path, nil
+		}
+	}
+
+	return "", scanner.Err()
+}
+
+// ReadCgroup reads the memory usage and the limits of a cgroup v2, given by its path below CgroupRoot
+func ReadCgroup(path string) (*Cgroup, error) {
+	return readCgroup(CgroupRoot, path)
+}
+
+func readCgroup(root, path string) (*Cgroup, error) {
+	basePath := filepath.Join(root, path)
+	cgroup := Cgroup{Path: path}
+
+	var err error
+
+	cgroup.Current, err = readUintFile(filepath.Join(basePath, "memory.current"))
+	if err != nil {
+		return nil, fmt.Errorf("could not read the memory of cgroup %s (is the memory controller enabled?): %w", path, err)
+	}
+
+	cgroup.Max, err = readCgroupLimit(filepath.Join(basePath, "memory.max"))
+	if err != nil {
+		return nil, err
+	}
+
+	cgroup.High, err = readCgroupLimit(filepath.Join(basePath, "memory.high"))
+	if err != nil {
+		return nil, err
+	}
+
+	stat, err := readKeyedFile(filepath.Join(basePath, "memory.stat"))
+	if err != nil {
+		return nil, err
+	}
+
+	cgroup.Anon = stat["anon"]
+	cgroup.File = stat["file"]
+	cgroup.InactiveFile = stat["inactive_file"]
+
+	cgroup.SwapCurrent, err = readUintFile(filepath.Join(basePath, "memory.swap.current"))
+
+	switch {
+	case err == nil:
+		cgroup.SwapAccounting = true
+	case errors.Is(err, os.ErrNotExist):
+		return &cgroup, nil
+	default:
+		return nil, err
+	}
+
+	cgroup.SwapMax, err = readCgroupLimit(filepath.Join(basePath, "memory.swap.max"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &cgroup, nil
+}
+
+// readCgroupLimit reads a limit which is either a number or "max", which is returned as nil.
+// A missing file is unlimited as well
+func readCgroupLimit(path string) (*uint64, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	if strings.TrimSpace(string(content)) == "max" {
+		return nil, nil
+	}
+
+	value, err := strconv.ParseUint(strings.TrimSpace(string(content)), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", path, err)
+	}
+
+	return &value, nil
+}
@@ -0,0 +1,101 @@
+package memory
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/shirou/gopsutil/v3/mem"
+)
+
+func TestReadCgroup(t *testing.T) {
+	cgroup, err := readCgroup("testdata/cgroup", "kubepods/pod1")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cgroup.Current != 1610612736 || cgroup.Max == nil || *cgroup.Max != 2147483648 || cgroup.High == nil {
+		t.Fatalf("expected %v, got %v", "1.5GiB of 2GiB", cgroup)
+	}
+
+	if cgroup.Anon != 1073741824 || cgroup.InactiveFile != 268435456 {
+		t.Fatalf("expected %v, got %v", "anon 1GiB and inactive_file 256MiB", cgroup)
+	}
+
+	if !cgroup.SwapAccounting || cgroup.SwapMax == nil || *cgroup.SwapMax != 1073741824 {
+		t.Fatalf("expected %v, got %v", "swap.max 1GiB", cgroup)
+	}
+
+	unlimited, err := readCgroup("testdata/cgroup", "user.slice")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if unlimited.Limited() || unlimited.SwapAccounting {
+		t.Fatalf("expected an unlimited cgroup without swap accounting, got %v", unlimited)
+	}
+
+	_, err = readCgroup("testdata/cgroup", "missing")
+	if err == nil {
+		t.Fatalf("expected an error, got none")
+	}
+}
+
+func TestDetectCgroup(t *testing.T) {
+	// In a container the process is in the root of its cgroup namespace
+	cgroup, err := detectCgroup("testdata/self_cgroup_root", "testdata/cgroup/kubepods/pod1")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cgroup == nil || cgroup.Max == nil {
+		t.Fatalf("expected a limited cgroup, got %v", cgroup)
+	}
+
+	// The root of the host has no limits
+	for _, selfCgroup := range []string{"testdata/self_cgroup_root", "testdata/self_cgroup_nested", "testdata/self_cgroup_v1"} {
+		cgroup, err = detectCgroup(selfCgroup, "testdata/cgroup")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if cgroup != nil {
+			t.Fatalf("expected no cgroup for %s, got %v", selfCgroup, cgroup)
+		}
+	}
+}
+
+func TestDetectCgroupMissing(t *testing.T) {
+	// The caller falls back to the host for missing files
+	_, err := detectCgroup("testdata/self_cgroup_missing", "testdata/cgroup")
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected %v, got %v", os.ErrNotExist, err)
+	}
+}
+
+func TestCgroupMem(t *testing.T) {
+	cgroup, err := readCgroup("testdata/cgroup", "kubepods/pod1")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	host := Mem{
+		VirtMem:  &mem.VirtualMemoryStat{Total: 16 * 1024 * 1024 * 1024},
+		SwapInfo: &mem.SwapMemoryStat{Total: 4 * 1024 * 1024 * 1024},
+	}
+
+	stats := cgroup.Mem(&host)
+
+	if stats.VirtMem.Total != 2147483648 || stats.VirtMem.UsedPercent != 75 {
+		t.Fatalf("expected %v, got %v", "75% of 2GiB", stats.VirtMem)
+	}
+
+	// The inactive file cache is available
+	if stats.VirtMem.Available != 2147483648-1610612736+268435456 || stats.MemAvailablePercentage != 37.5 {
+		t.Fatalf("expected %v, got %v", "768MiB (37.5%) available", stats.VirtMem)
+	}
+
+	if stats.SwapInfo.Total != 1073741824 || stats.VirtMem.SwapTotal != 1073741824 {
+		t.Fatalf("expected %v, got %v", "1GiB swap", stats.SwapInfo)
+	}
+}
@@ -17,6 +17,33 @@ type MemConfig struct {
 	SwapUsedPercentage thresholds.Thresholds
 	SwapFreePercentage thresholds.Thresholds
 
+	// Committed_AS in percent of the CommitLimit
+	CommittedPercentage thresholds.Thresholds
+	CommittedMemory     bool
+
+	// OOM kills since the previous execution, of the system and of every cgroup in OOMCgroups
+	OOMKills      thresholds.Thresholds
+	TrackOOMKills bool
+	// OOMCgroups are paths of cgroups v2 below the CgroupRoot, e.g. system.slice/mysql.service
+	OOMCgroups []string
+
+	// Pages swapped in and out and major page faults per second since the previous execution
+	SwapInRate     thresholds.Thresholds
+	SwapOutRate    thresholds.Thresholds
+	MajorFaultRate thresholds.Thresholds
+	SwapActivity   bool
+
+	// Cgroup is the path of a cgroup v2 below the CgroupRoot to check instead of the host
+	Cgroup string
+	// NoCgroupDetection disables the use of the cgroup of a container with a memory limit
+	NoCgroupDetection bool
+
+	// Thresholds on the fields of /proc/meminfo by name, e.g. Slab or Dirty
+	WarningMeminfo  thresholds.NamedThresholds
+	CriticalMeminfo thresholds.NamedThresholds
+	// MeminfoBreakdown reports the BreakdownFields in addition to the fields with thresholds
+	MeminfoBreakdown bool
+
 	Verbose              bool
 	PercentageInPerfdata bool
 }
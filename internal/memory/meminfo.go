@@ -0,0 +1,99 @@
+package memory
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const meminfoPath = "/proc/meminfo"
+
+// BreakdownFields are the fields of /proc/meminfo which are reported by the breakdown
+var BreakdownFields = []string{
+	"Buffers",
+	"Cached",
+	"Shmem",
+	"Mapped",
+	"Dirty",
+	"Writeback",
+	"Slab",
+	"SReclaimable",
+	"SUnreclaim",
+	"PageTables",
+	"HugePages_Total",
+	"HugePages_Free",
+	"HugePages_Rsvd",
+	"HugePages_Surp",
+	"Hugepagesize",
+	"Hugetlb",
+}
+
+// Meminfo contains the fields of /proc/meminfo. Sizes are converted to bytes,
+// the HugePages_* fields are numbers of pages
+type Meminfo map[string]uint64
+
+// IsSize returns false for the fields which are not sizes but numbers of pages
+func IsSize(name string) bool {
+	return !strings.HasPrefix(name, "HugePages_")
+}
+
+// Names returns the sorted names of all fields
+func (m Meminfo) Names() []string {
+	names := make([]string, 0, len(m))
+
+	for name := range m {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// ReadMeminfo reads all fields of /proc/meminfo
+func ReadMeminfo() (Meminfo, error) {
+	return readMeminfo(meminfoPath)
+}
+
+func readMeminfo(path string) (Meminfo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	meminfo := make(Meminfo)
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		name, value, found := strings.Cut(scanner.Text(), ":")
+		if !found {
+			continue
+		}
+
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("could not parse %s: no value for %s", path, name)
+		}
+
+		number, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse %s of %s: %w", name, path, err)
+		}
+
+		if len(fields) > 1 && fields[1] == "kB" {
+			number *= 1024
+		}
+
+		meminfo[name] = number
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return meminfo, nil
+}
@@ -0,0 +1,35 @@
+package memory
+
+import (
+	"testing"
+)
+
+func TestReadMeminfo(t *testing.T) {
+	meminfo, err := readMeminfo("testdata/meminfo_breakdown")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for name, expected := range map[string]uint64{
+		"MemTotal":        6158152 * 1024,
+		"Active(anon)":    1796 * 1024,
+		"SUnreclaim":      19656 * 1024,
+		"HugePages_Total": 16,
+		"Hugetlb":         32768 * 1024,
+	} {
+		if meminfo[name] != expected {
+			t.Fatalf("expected %v for %s, got %v", expected, name, meminfo[name])
+		}
+	}
+
+	if IsSize("HugePages_Free") || !IsSize("Hugepagesize") {
+		t.Fatalf("expected only HugePages_Free to be a number of pages")
+	}
+}
+
+func TestReadMeminfoMissing(t *testing.T) {
+	_, err := readMeminfo("testdata/missing")
+	if err == nil {
+		t.Fatalf("expected an error, got none")
+	}
+}
@@ -12,6 +12,8 @@ type Mem struct {
 	MemAvailablePercentage float64
 
 	SwapInfo *mem.SwapMemoryStat
+
+	Meminfo Meminfo
 }
 
 func LoadMemStat() (m *Mem, err error) {
@@ -33,5 +35,10 @@ func LoadMemStat() (m *Mem, err error) {
 
 	m.SwapInfo = swapMem
 
+	m.Meminfo, err = ReadMeminfo()
+	if err != nil {
+		return nil, fmt.Errorf("could not load meminfo: %w", err)
+	}
+
 	return
 }
@@ -0,0 +1,133 @@
+package memory
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/NETWAYS/go-check/convert"
+)
+
+const procSysVMPath = "/proc/sys/vm"
+
+// The modes of vm.overcommit_memory
+const (
+	OvercommitHeuristic = 0
+	OvercommitAlways    = 1
+	OvercommitNever     = 2
+)
+
+// Overcommit describes the committed memory and how the kernel limits it
+type Overcommit struct {
+	Mode int
+	// Ratio is the percentage of the RAM (without hugepages) which may be committed in addition to the swap
+	Ratio uint64
+	// Kbytes replaces the Ratio if it is not zero
+	Kbytes uint64
+
+	// CommittedAS is the memory which would be needed if all allocations were used
+	CommittedAS uint64
+	CommitLimit uint64
+
+	// The sizes the CommitLimit is derived from
+	MemTotal  uint64
+	Hugetlb   uint64
+	SwapTotal uint64
+}
+
+// Enforced returns true if allocations beyond the CommitLimit fail
+func (o *Overcommit) Enforced() bool {
+	return o.Mode == OvercommitNever
+}
+
+// CommittedPercentage returns the committed memory in percent of the CommitLimit.
+// It may exceed 100% if the limit is not enforced
+func (o *Overcommit) CommittedPercentage() float64 {
+	if o.CommitLimit == 0 {
+		return 0
+	}
+
+	return float64(o.CommittedAS) / float64(o.CommitLimit) * 100
+}
+
+// Explain describes the mode and how the CommitLimit is derived
+func (o *Overcommit) Explain() string {
+	switch o.Mode {
+	case OvercommitHeuristic:
+		return "overcommit mode 0 (heuristic): the CommitLimit is not enforced, only obviously excessive allocations fail"
+	case OvercommitAlways:
+		return "overcommit mode 1 (always): the CommitLimit is not enforced, allocations never fail"
+	case OvercommitNever:
+		if o.Kbytes > 0 {
+			return fmt.Sprintf("overcommit mode 2 (never): allocations beyond the CommitLimit fail, CommitLimit = overcommit_kbytes %s + swap %s",
+				convert.BytesIEC(o.Kbytes*1024), convert.BytesIEC(o.SwapTotal))
+		}
+
+		return fmt.Sprintf("overcommit mode 2 (never): allocations beyond the CommitLimit fail, CommitLimit = (RAM %s - hugepages %s) * overcommit_ratio %d%% + swap %s",
+			convert.BytesIEC(o.MemTotal), convert.BytesIEC(o.Hugetlb), o.Ratio, convert.BytesIEC(o.SwapTotal))
+	default:
+		return fmt.Sprintf("unknown overcommit mode %d", o.Mode)
+	}
+}
+
+// ReadOvercommit reads the overcommit settings from /proc/sys/vm and the committed memory from meminfo
+func ReadOvercommit(meminfo Meminfo) (*Overcommit, error) {
+	return readOvercommit(procSysVMPath, meminfo)
+}
+
+func readOvercommit(path string, meminfo Meminfo) (*Overcommit, error) {
+	overcommit := Overcommit{
+		CommittedAS: meminfo["Committed_AS"],
+		CommitLimit: meminfo["CommitLimit"],
+		MemTotal:    meminfo["MemTotal"],
+		SwapTotal:   meminfo["SwapTotal"],
+	}
+
+	if _, ok := meminfo["CommitLimit"]; !ok {
+		return nil, errors.New("CommitLimit not found in meminfo")
+	}
+
+	// Hugetlb also contains hugepages of other sizes, but is not available on older kernels
+	if hugetlb, ok := meminfo["Hugetlb"]; ok {
+		overcommit.Hugetlb = hugetlb
+	} else {
+		overcommit.Hugetlb = meminfo["HugePages_Total"] * meminfo["Hugepagesize"]
+	}
+
+	mode, err := readUintFile(filepath.Join(path, "overcommit_memory"))
+	if err != nil {
+		return nil, err
+	}
+
+	overcommit.Mode = int(mode)
+
+	overcommit.Ratio, err = readUintFile(filepath.Join(path, "overcommit_ratio"))
+	if err != nil {
+		return nil, err
+	}
+
+	// overcommit_kbytes exists since Linux 3.14
+	overcommit.Kbytes, err = readUintFile(filepath.Join(path, "overcommit_kbytes"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	return &overcommit, nil
+}
+
+func readUintFile(path string) (uint64, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := strconv.ParseUint(strings.TrimSpace(string(content)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("could not parse %s: %w", path, err)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,47 @@
+package memory
+
+import (
+	"testing"
+)
+
+func TestReadOvercommit(t *testing.T) {
+	meminfo := Meminfo{
+		"MemTotal":     8 * 1024 * 1024 * 1024,
+		"SwapTotal":    2 * 1024 * 1024 * 1024,
+		"Hugetlb":      1024 * 1024 * 1024,
+		"CommitLimit":  7600 * 1024 * 1024,
+		"Committed_AS": 5700 * 1024 * 1024,
+	}
+
+	overcommit, err := readOvercommit("testdata/sys/vm", meminfo)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !overcommit.Enforced() || overcommit.Ratio != 80 {
+		t.Fatalf("expected %v, got %v", "mode 2 with ratio 80", overcommit)
+	}
+
+	if overcommit.CommittedPercentage() != 75 {
+		t.Fatalf("expected %v, got %v", 75, overcommit.CommittedPercentage())
+	}
+
+	expected := "overcommit mode 2 (never): allocations beyond the CommitLimit fail, CommitLimit = (RAM 8GiB - hugepages 1024MiB) * overcommit_ratio 80% + swap 2GiB"
+	if overcommit.Explain() != expected {
+		t.Fatalf("expected %v, got %v", expected, overcommit.Explain())
+	}
+
+	overcommit.Kbytes = 4 * 1024 * 1024
+
+	expected = "overcommit mode 2 (never): allocations beyond the CommitLimit fail, CommitLimit = overcommit_kbytes 4GiB + swap 2GiB"
+	if overcommit.Explain() != expected {
+		t.Fatalf("expected %v, got %v", expected, overcommit.Explain())
+	}
+}
+
+func TestReadOvercommitMissing(t *testing.T) {
+	_, err := readOvercommit("testdata/missing", Meminfo{"CommitLimit": 0})
+	if err == nil {
+		t.Fatalf("expected an error, got none")
+	}
+}
@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	vmstatPath = "/proc/vmstat"
+	// CgroupRoot is the mount point of the cgroup v2 hierarchy
+	CgroupRoot = "/sys/fs/cgroup"
+)
+
+// ReadVmstat reads the counters of /proc/vmstat
+func ReadVmstat() (map[string]uint64, error) {
+	return readKeyedFile(vmstatPath)
+}
+
+// ReadMemoryEvents reads the memory.events of a cgroup v2, given by its path below CgroupRoot
+func ReadMemoryEvents(cgroup string) (map[string]uint64, error) {
+	return readKeyedFile(filepath.Join(CgroupRoot, cgroup, "memory.events"))
+}
+
+// readKeyedFile reads a file with one "name value" pair per line
+func readKeyedFile(path string) (map[string]uint64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	values := make(map[string]uint64)
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		name, value, found := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		if !found {
+			continue
+		}
+
+		values[name], err = strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse %s of %s: %w", name, path, err)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
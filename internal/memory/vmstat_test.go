@@ -0,0 +1,25 @@
+package memory
+
+import (
+	"testing"
+)
+
+func TestReadKeyedFile(t *testing.T) {
+	vmstat, err := readKeyedFile("testdata/vmstat")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if vmstat["oom_kill"] != 3 || vmstat["pswpout"] != 5400 {
+		t.Fatalf("expected %v, got %v", "oom_kill 3 and pswpout 5400", vmstat)
+	}
+
+	events, err := readKeyedFile("testdata/cgroup/system.slice/mysql.service/memory.events")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if events["oom"] != 2 || events["oom_kill"] != 1 {
+		t.Fatalf("expected %v, got %v", "oom 2 and oom_kill 1", events)
+	}
+}
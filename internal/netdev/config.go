@@ -14,13 +14,68 @@ type CheckConfig struct {
 	NotUpIsOK      bool
 	UnknownIsOk    bool
 
+	RxBitsPerSecond    thresholds.Thresholds
+	TxBitsPerSecond    thresholds.Thresholds
+	RxPacketsPerSecond thresholds.Thresholds
+	TxPacketsPerSecond thresholds.Thresholds
+	// Percentage of the link speed
+	RxUtilization thresholds.Thresholds
+	TxUtilization thresholds.Thresholds
+
+	// Applied to both directions
+	ErrorsPerSecond  thresholds.Thresholds
+	DroppedPerSecond thresholds.Thresholds
+	// Percentage of all packets
+	ErrorRatio thresholds.Thresholds
+	DropRatio  thresholds.Thresholds
+
+	// Thresholds on the rate of any statistic
+	WarningStatisticRate  thresholds.NamedThresholds
+	CriticalStatisticRate thresholds.NamedThresholds
+
+	// Expected link properties, a mismatch results in WARNING or CRITICAL with LinkMismatchIsCritical
+	MinSpeed               Expectations
+	MTU                    Expectations
+	FullDuplex             []string
+	LinkMismatchIsCritical bool
+	// Carrier changes since the previous execution
+	CarrierChanges thresholds.Thresholds
+
 	Filters Filter
 }
 
+// StateEnabled returns whether thresholds on rates or carrier changes are configured,
+// which are computed from the counters of the previous execution
+func (c *CheckConfig) StateEnabled() bool {
+	return c.RxBitsPerSecond.IsSet() || c.TxBitsPerSecond.IsSet() ||
+		c.RxPacketsPerSecond.IsSet() || c.TxPacketsPerSecond.IsSet() ||
+		c.RxUtilization.IsSet() || c.TxUtilization.IsSet() ||
+		c.ErrorsPerSecond.IsSet() || c.DroppedPerSecond.IsSet() ||
+		c.ErrorRatio.IsSet() || c.DropRatio.IsSet() ||
+		len(c.WarningStatisticRate) > 0 || len(c.CriticalStatisticRate) > 0 ||
+		c.CarrierChanges.IsSet()
+}
+
+// LinkEnabled returns whether the link properties should be checked, which are only reported with expectations
+// or thresholds on the carrier changes
+func (c *CheckConfig) LinkEnabled() bool {
+	return len(c.MinSpeed) > 0 || len(c.MTU) > 0 || len(c.FullDuplex) > 0 || c.CarrierChanges.IsSet()
+}
+
 type Filter struct {
 	IncludeInterfaceNames []string
 	ExcludeInterfaceNames []string
 
-	IncludeIPRange []net.IP
-	ExcludeIPRange []net.IP
+	// Kinds of interfaces, see GetIfaceKinds
+	IncludeInterfaceTypes []string
+	ExcludeInterfaceTypes []string
+
+	// Interfaces with at least one address within the given networks
+	IncludeIPRange []net.IPNet
+	ExcludeIPRange []net.IPNet
+}
+
+// FiltersByAddress returns whether the addresses of the interfaces are needed to filter them
+func (f *Filter) FiltersByAddress() bool {
+	return len(f.IncludeIPRange) > 0 || len(f.ExcludeIPRange) > 0
 }
@@ -0,0 +1,79 @@
+package netdev
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ExpectationsType is the flag type of Expectations
+const ExpectationsType = "Regexp=Value"
+
+// Expectation is an expected value for all the interfaces whose names match Pattern
+type Expectation struct {
+	Pattern *regexp.Regexp
+	Value   int64
+}
+
+// Expectations is a flag value for expected values per interface, which may be
+// repeated in the form of "regexp=value"
+type Expectations []Expectation
+
+func (e *Expectations) Set(value string) error {
+	pattern, number, found := strings.Cut(value, "=")
+	if !found || pattern == "" {
+		return fmt.Errorf("expected a value in the form of regexp=value, got %q", value)
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+
+	parsed, err := strconv.ParseInt(strings.TrimSpace(number), 10, 64)
+	if err != nil {
+		return fmt.Errorf("could not parse %q as a number: %w", number, err)
+	}
+
+	*e = append(*e, Expectation{Pattern: re, Value: parsed})
+
+	return nil
+}
+
+func (e *Expectations) String() string {
+	values := make([]string, len(*e))
+
+	for i := range *e {
+		values[i] = (*e)[i].Pattern.String() + "=" + strconv.FormatInt((*e)[i].Value, 10)
+	}
+
+	return strings.Join(values, ",")
+}
+
+func (e *Expectations) Type() string {
+	return ExpectationsType
+}
+
+// IsRepeatable reports that every occurrence of the flag adds an expectation
+func (e *Expectations) IsRepeatable() bool {
+	return true
+}
+
+// Lookup returns the expected value for the interface name.
+// If several patterns match, the last one given wins, so general patterns should come first
+func (e Expectations) Lookup(name string) (int64, bool) {
+	var (
+		value int64
+		found bool
+	)
+
+	for i := range e {
+		if e[i].Pattern.MatchString(name) {
+			value = e[i].Value
+			found = true
+		}
+	}
+
+	return value, found
+}
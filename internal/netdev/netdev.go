@@ -1,12 +1,17 @@
 package netdev
 
 import (
+	"errors"
+	"fmt"
+	"net"
 	"os"
 	"path"
 	"strconv"
 	"strings"
+	"syscall"
 
 	"github.com/NETWAYS/check_system_basics/internal/common/filter"
+	"github.com/NETWAYS/check_system_basics/internal/common/state"
 )
 
 // types and constants
@@ -23,6 +28,16 @@ const (
 	netDevicePath = "/sys/class/net"
 )
 
+// SpeedUnknown is used if the link speed of an interface can not be determined,
+// which is the case for most virtual interfaces and interfaces without a link
+const SpeedUnknown = -1
+
+const (
+	DuplexFull    = "full"
+	DuplexHalf    = "half"
+	DuplexUnknown = "unknown"
+)
+
 func TranslateIfaceState(state uint) string {
 	switch state {
 	case Up:
@@ -40,29 +55,51 @@ func TranslateIfaceState(state uint) string {
 	}
 }
 
+// Kinds of interfaces
+const (
+	KindPhysical = "physical"
+	KindWireless = "wireless"
+	KindLoopback = "loopback"
+	KindBridge   = "bridge"
+	KindBond     = "bond"
+	KindVlan     = "vlan"
+	KindVirtual  = "virtual"
+)
+
+func GetIfaceKinds() []string {
+	return []string{
+		KindPhysical,
+		KindWireless,
+		KindLoopback,
+		KindBridge,
+		KindBond,
+		KindVlan,
+		KindVirtual,
+	}
+}
+
+// arphrdLoopback is the hardware type of loopback interfaces (see linux/if_arp.h)
+const arphrdLoopback = "772"
+
 // Constants and the string array MUST be kept in sync!
+// There are no tx_frame and tx_multicast statistics in sysfs, the multicast
+// statistic only counts received packets.
 const (
 	rx_bytes int = iota
 	rx_errs
 	rx_drop
 	rx_packets
-	/*
-		rx_fifo uint
-		rx_frame uint
-		rx_compressed uint
-		rx_multicast uint
-	*/
+	rx_fifo
+	rx_frame
+	rx_compressed
+	rx_multicast
 
 	tx_bytes
 	tx_errs
 	tx_drop
 	tx_packets
-	/*
-		tx_fifo uint
-		tx_frame uint
-		tx_compressed uint
-		tx_multicast uint
-	*/
+	tx_fifo
+	tx_compressed
 	metricLength
 )
 
@@ -72,23 +109,17 @@ func GetIfaceStatNames() []string {
 		"rx_errors",
 		"rx_dropped",
 		"rx_packets",
-		/*
-			"rx_fifo",
-			"rx_frame"
-			"rx_compressed"
-			"rx_multicast"
-		*/
+		"rx_fifo_errors",
+		"rx_frame_errors",
+		"rx_compressed",
+		"multicast",
 
 		"tx_bytes",
 		"tx_errors",
 		"tx_dropped",
 		"tx_packets",
-		/*
-			"tx_fifo",
-			"tx_frame",
-			"tx_compressed",
-			"tx_multicast",
-		*/
+		"tx_fifo_errors",
+		"tx_compressed",
 	}
 }
 
@@ -96,24 +127,96 @@ type statistics [metricLength]uint64
 
 type IfaceData struct {
 	Name      string
+	Kind      string
 	Operstate uint
-	Metrics   statistics
+	// Speed is the link speed in Mbit/s
+	Speed int64
+	// Duplex is one of "full", "half" or "unknown"
+	Duplex         string
+	MTU            int64
+	Carrier        bool
+	CarrierChanges uint64
+	Metrics        statistics
+	Addresses      []net.IP
+}
+
+// IfaceRates contains the change per second of every interface statistic since the previous execution
+type IfaceRates [metricLength]float64
+
+func (r IfaceRates) RxBitsPerSecond() float64 {
+	return r[rx_bytes] * 8
+}
+
+func (r IfaceRates) TxBitsPerSecond() float64 {
+	return r[tx_bytes] * 8
+}
+
+func (r IfaceRates) RxPacketsPerSecond() float64 {
+	return r[rx_packets]
+}
+
+func (r IfaceRates) TxPacketsPerSecond() float64 {
+	return r[tx_packets]
+}
+
+func (r IfaceRates) RxErrorsPerSecond() float64 {
+	return r[rx_errs]
+}
+
+func (r IfaceRates) TxErrorsPerSecond() float64 {
+	return r[tx_errs]
+}
+
+func (r IfaceRates) RxDroppedPerSecond() float64 {
+	return r[rx_drop]
+}
+
+func (r IfaceRates) TxDroppedPerSecond() float64 {
+	return r[tx_drop]
+}
+
+// Get returns the rate of the statistic with the given name (as in GetIfaceStatNames)
+func (r IfaceRates) Get(name string) (float64, bool) {
+	for idx, stat := range GetIfaceStatNames() {
+		if stat == name {
+			return r[idx], true
+		}
+	}
+
+	return 0, false
+}
+
+// Ratio returns the percentage of the failed packets in relation to all packets.
+// The packet statistics only count the successful packets, therefore the failed
+// packets are added to get the total.
+func Ratio(failedPerSecond, packetsPerSecond float64) float64 {
+	if failedPerSecond+packetsPerSecond == 0 {
+		return 0
+	}
+
+	return failedPerSecond / (failedPerSecond + packetsPerSecond) * 100
 }
 
 const (
 	IfaceDataName = iota
+	IfaceDataKind
 )
 
 func (iface IfaceData) GetFilterableValue(ident uint) string {
 	switch ident {
 	case IfaceDataName:
 		return iface.Name
+	case IfaceDataKind:
+		return iface.Kind
 	default:
 		return ""
 	}
 }
 
-func GetAllInterfaces() ([]IfaceData, error) {
+// GetAllInterfaces reads the state, statistics and link properties of all interfaces.
+// The addresses are only resolved with withAddresses, since they are only needed to filter by IP ranges.
+// Interfaces which vanish while being read are skipped.
+func GetAllInterfaces(withAddresses bool) ([]IfaceData, error) {
 	interfaces, err := listInterfaces()
 	if err != nil {
 		return []IfaceData{}, err
@@ -123,25 +226,60 @@ func GetAllInterfaces() ([]IfaceData, error) {
 		return []IfaceData{}, nil
 	}
 
-	result := make([]IfaceData, len(interfaces))
+	result := make([]IfaceData, 0, len(interfaces))
 
 	for i := range interfaces {
-		result[i].Name = interfaces[i]
+		iface := IfaceData{Name: interfaces[i]}
 
-		err = getInterfaceState(&result[i])
+		err = readInterface(&iface, withAddresses)
 		if err != nil {
-			return result, err
-		}
+			if vanished(iface.Name, err) {
+				continue
+			}
 
-		err = getInfacesStatistics(&result[i])
-		if err != nil {
 			return result, err
 		}
+
+		result = append(result, iface)
 	}
 
 	return result, nil
 }
 
+func readInterface(data *IfaceData, withAddresses bool) error {
+	data.Kind = getInterfaceKind(path.Join(netDevicePath, data.Name))
+
+	err := getInterfaceState(data)
+	if err != nil {
+		return err
+	}
+
+	err = getInfacesStatistics(data)
+	if err != nil {
+		return err
+	}
+
+	getInterfaceSpeed(data)
+	getInterfaceLink(path.Join(netDevicePath, data.Name), data)
+
+	if withAddresses {
+		return getInterfaceAddresses(data)
+	}
+
+	return nil
+}
+
+// vanished returns whether the interface was removed while being read, e.g. a veth pair of a stopped container
+func vanished(name string, err error) bool {
+	if errors.Is(err, os.ErrNotExist) || errors.Is(err, syscall.ENODEV) {
+		return true
+	}
+
+	_, statErr := os.Stat(path.Join(netDevicePath, name))
+
+	return errors.Is(statErr, os.ErrNotExist)
+}
+
 func listInterfaces() ([]string, error) {
 	file, err := os.Open(netDevicePath)
 	if err != nil {
@@ -157,6 +295,11 @@ func listInterfaces() ([]string, error) {
 
 	for idx := range devices {
 		fileInfo, err := os.Stat(path.Join(netDevicePath, devices[idx]))
+		if errors.Is(err, os.ErrNotExist) {
+			// The interface was removed in the meantime
+			continue
+		}
+
 		if err != nil {
 			// Could not stat file there, not sure if this can be handled usefully. Just die for now.
 			return []string{}, err
@@ -205,6 +348,197 @@ func getInterfaceState(data *IfaceData) error {
 	}
 }
 
+// getInterfaceKind classifies the interface in basePath. Bridges and bonds are recognized by their
+// subdirectories, vlan and wireless interfaces by the DEVTYPE in uevent and every other interface
+// with an underlying device (the "device" symlink) is a physical one.
+// Everything else (tun, veth, dummy and so on) is considered virtual.
+func getInterfaceKind(basePath string) string {
+	hwType, err := os.ReadFile(path.Join(basePath, "type"))
+	if err == nil && strings.TrimSpace(string(hwType)) == arphrdLoopback {
+		return KindLoopback
+	}
+
+	if isDirectory(path.Join(basePath, "bridge")) {
+		return KindBridge
+	}
+
+	if isDirectory(path.Join(basePath, "bonding")) {
+		return KindBond
+	}
+
+	switch getUeventValue(path.Join(basePath, "uevent"), "DEVTYPE") {
+	case "vlan":
+		return KindVlan
+	case "wlan":
+		return KindWireless
+	case "bridge":
+		return KindBridge
+	case "bond":
+		return KindBond
+	}
+
+	_, err = os.Stat(path.Join(basePath, "device"))
+	if err == nil {
+		return KindPhysical
+	}
+
+	return KindVirtual
+}
+
+func isDirectory(name string) bool {
+	fileInfo, err := os.Stat(name)
+
+	return err == nil && fileInfo.IsDir()
+}
+
+// getUeventValue returns the value of key in a uevent file or an empty string if it is not present
+func getUeventValue(name, key string) string {
+	content, err := os.ReadFile(name)
+	if err != nil {
+		return ""
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		if value, found := strings.CutPrefix(line, key+"="); found {
+			return strings.TrimSpace(value)
+		}
+	}
+
+	return ""
+}
+
+// getInterfaceSpeed reads the link speed of the interface. Reading the speed fails
+// for many virtual interfaces or returns nonsense for interfaces without a link,
+// in these cases the speed is set to SpeedUnknown
+func getInterfaceSpeed(data *IfaceData) {
+	data.Speed = SpeedUnknown
+
+	bytes, err := os.ReadFile(path.Join(netDevicePath, data.Name, "speed"))
+	if err != nil {
+		return
+	}
+
+	speed, err := strconv.ParseInt(strings.TrimSpace(string(bytes)), 10, 64)
+	if err != nil {
+		return
+	}
+
+	// Some drivers report (uint32)-1 instead of -1
+	if speed <= 0 || speed == 4294967295 {
+		return
+	}
+
+	data.Speed = speed
+}
+
+// getInterfaceLink reads the duplex, MTU and carrier of the interface in basePath.
+// Most of these files can not be read if the interface is down, which is not an error here
+func getInterfaceLink(basePath string, data *IfaceData) {
+	data.Duplex = DuplexUnknown
+
+	duplex, err := os.ReadFile(path.Join(basePath, "duplex"))
+	if err == nil && strings.TrimSpace(string(duplex)) != "" {
+		data.Duplex = strings.TrimSpace(string(duplex))
+	}
+
+	mtu, err := os.ReadFile(path.Join(basePath, "mtu"))
+	if err == nil {
+		data.MTU, _ = strconv.ParseInt(strings.TrimSpace(string(mtu)), 10, 64)
+	}
+
+	carrier, err := os.ReadFile(path.Join(basePath, "carrier"))
+	data.Carrier = err == nil && strings.TrimSpace(string(carrier)) == "1"
+
+	carrierChanges, err := os.ReadFile(path.Join(basePath, "carrier_changes"))
+	if err == nil {
+		data.CarrierChanges, _ = strconv.ParseUint(strings.TrimSpace(string(carrierChanges)), 10, 64)
+	}
+}
+
+// getInterfaceAddresses retrieves the IPv4 and IPv6 addresses assigned to the interface
+func getInterfaceAddresses(data *IfaceData) error {
+	iface, err := net.InterfaceByName(data.Name)
+	if err != nil {
+		return fmt.Errorf("could not get addresses of interface %s: %w", data.Name, err)
+	}
+
+	addresses, err := iface.Addrs()
+	if err != nil {
+		return fmt.Errorf("could not get addresses of interface %s: %w", data.Name, err)
+	}
+
+	data.Addresses = make([]net.IP, 0, len(addresses))
+
+	for _, address := range addresses {
+		if ipNet, ok := address.(*net.IPNet); ok {
+			data.Addresses = append(data.Addresses, ipNet.IP)
+		}
+	}
+
+	return nil
+}
+
+// HasAddressIn returns true if at least one address of the interface is within one of the networks
+func (iface *IfaceData) HasAddressIn(networks []net.IPNet) bool {
+	for _, address := range iface.Addresses {
+		for i := range networks {
+			if networks[i].Contains(address) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Utilization returns the percentage of the link speed which is used by the given bit rate.
+// The second return value is false if the link speed is unknown
+func (iface *IfaceData) Utilization(bitsPerSecond float64) (float64, bool) {
+	if iface.Speed == SpeedUnknown {
+		return 0, false
+	}
+
+	return bitsPerSecond / (float64(iface.Speed) * 1000 * 1000) * 100, true
+}
+
+// GetInterfaceRates stores the statistics of the interface in the state and computes
+// the rates since the previous execution.
+// All the statistics are stored, even if the rates can not be computed.
+func GetInterfaceRates(store *state.Store, iface *IfaceData) (IfaceRates, error) {
+	var (
+		rates    IfaceRates
+		firstErr error
+	)
+
+	for idx, stat := range GetIfaceStatNames() {
+		rate, err := store.Rate(iface.Name+"_"+stat, iface.Metrics[idx])
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+
+			continue
+		}
+
+		rates[idx] = rate
+	}
+
+	return rates, firstErr
+}
+
+// FormatBitRate returns a human readable representation of a bit rate
+func FormatBitRate(bitsPerSecond float64) string {
+	units := []string{"bit/s", "kbit/s", "Mbit/s", "Gbit/s", "Tbit/s"}
+
+	idx := 0
+	for bitsPerSecond >= 1000 && idx < len(units)-1 {
+		bitsPerSecond /= 1000
+		idx++
+	}
+
+	return fmt.Sprintf("%.2f %s", bitsPerSecond, units[idx])
+}
+
 // Get interfaces statistics
 // @result: ifaceStats, err
 func getInfacesStatistics(data *IfaceData) error {
@@ -231,6 +565,44 @@ func getInfacesStatistics(data *IfaceData) error {
 	return nil
 }
 
+// PatternMatch holds the interfaces matching a name pattern
+type PatternMatch struct {
+	Pattern string
+	Found   int
+	Up      int
+	// NotUp are the names of the matching interfaces which are not up
+	NotUp []string
+}
+
+// MatchInterfaces returns the matching and up interfaces for every name pattern
+func MatchInterfaces(interfaces []IfaceData, patterns []string) ([]PatternMatch, error) {
+	matches := make([]PatternMatch, 0, len(patterns))
+
+	for _, pattern := range patterns {
+		found, err := filter.Filter(interfaces, &[]string{pattern}, IfaceDataName, filter.Options{
+			MatchIncludedInResult: true,
+			RegexpMatching:        true,
+		})
+		if err != nil {
+			return []PatternMatch{}, err
+		}
+
+		match := PatternMatch{Pattern: pattern, Found: len(found), NotUp: []string{}}
+
+		for i := range found {
+			if found[i].Operstate == Up {
+				match.Up++
+			} else {
+				match.NotUp = append(match.NotUp, found[i].Name)
+			}
+		}
+
+		matches = append(matches, match)
+	}
+
+	return matches, nil
+}
+
 func FilterInterfaces(interfaces *[]IfaceData, filters *Filter) ([]IfaceData, error) {
 	foo, err := filter.Filter(*interfaces,
 		&filters.IncludeInterfaceNames,
@@ -258,5 +630,55 @@ func FilterInterfaces(interfaces *[]IfaceData, filters *Filter) ([]IfaceData, er
 		return []IfaceData{}, err
 	}
 
+	foo, err = filter.Filter(foo,
+		&filters.IncludeInterfaceTypes,
+		IfaceDataKind,
+		filter.Options{
+			MatchIncludedInResult: true,
+			RegexpMatching:        false,
+			EmptyFilterNoMatch:    false,
+		},
+	)
+	if err != nil {
+		return []IfaceData{}, err
+	}
+
+	foo, err = filter.Filter(foo,
+		&filters.ExcludeInterfaceTypes,
+		IfaceDataKind,
+		filter.Options{
+			MatchIncludedInResult: false,
+			RegexpMatching:        false,
+			EmptyFilterNoMatch:    false,
+		},
+	)
+	if err != nil {
+		return []IfaceData{}, err
+	}
+
+	if len(filters.IncludeIPRange) != 0 {
+		newList := make([]IfaceData, 0, len(foo))
+
+		for i := range foo {
+			if foo[i].HasAddressIn(filters.IncludeIPRange) {
+				newList = append(newList, foo[i])
+			}
+		}
+
+		foo = newList
+	}
+
+	if len(filters.ExcludeIPRange) != 0 {
+		newList := make([]IfaceData, 0, len(foo))
+
+		for i := range foo {
+			if !foo[i].HasAddressIn(filters.ExcludeIPRange) {
+				newList = append(newList, foo[i])
+			}
+		}
+
+		foo = newList
+	}
+
 	return foo, nil
 }
@@ -0,0 +1,250 @@
+package netdev
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"path"
+	"reflect"
+	"syscall"
+	"testing"
+)
+
+func TestFormatBitRate(t *testing.T) {
+	testCases := map[float64]string{
+		0:               "0.00 bit/s",
+		999:             "999.00 bit/s",
+		1500:            "1.50 kbit/s",
+		10 * 1000 * 1e6: "10.00 Gbit/s",
+	}
+
+	for input, expected := range testCases {
+		if FormatBitRate(input) != expected {
+			t.Fatalf("expected %v, got %v", expected, FormatBitRate(input))
+		}
+	}
+}
+
+func TestUtilization(t *testing.T) {
+	iface := IfaceData{Name: "eth0", Speed: 100}
+
+	utilization, ok := iface.Utilization(50 * 1000 * 1000)
+	if !ok || utilization != 50 {
+		t.Fatalf("expected %v, got %v", 50, utilization)
+	}
+
+	iface.Speed = SpeedUnknown
+
+	_, ok = iface.Utilization(50 * 1000 * 1000)
+	if ok {
+		t.Fatalf("expected utilization to be unknown without link speed")
+	}
+}
+
+func TestFilterInterfacesByIPRange(t *testing.T) {
+	_, privateNet, _ := net.ParseCIDR("10.20.0.0/16")
+	_, documentationNet, _ := net.ParseCIDR("2001:db8::/32")
+
+	interfaces := []IfaceData{
+		{Name: "ens1", Addresses: []net.IP{net.ParseIP("10.20.1.1"), net.ParseIP("fe80::1")}},
+		{Name: "ens2", Addresses: []net.IP{net.ParseIP("192.168.1.1"), net.ParseIP("2001:db8::1")}},
+		{Name: "ens3"},
+	}
+
+	result, err := FilterInterfaces(&interfaces, &Filter{IncludeIPRange: []net.IPNet{*privateNet, *documentationNet}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(result) != 2 || result[0].Name != "ens1" || result[1].Name != "ens2" {
+		t.Fatalf("expected ens1 and ens2, got %v", result)
+	}
+
+	result, err = FilterInterfaces(&interfaces, &Filter{ExcludeIPRange: []net.IPNet{*documentationNet}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(result) != 2 || result[0].Name != "ens1" || result[1].Name != "ens3" {
+		t.Fatalf("expected ens1 and ens3, got %v", result)
+	}
+}
+
+func TestMatchInterfaces(t *testing.T) {
+	interfaces := []IfaceData{{Name: "ens1", Operstate: Up}, {Name: "ens3", Operstate: Down}}
+
+	matches, err := MatchInterfaces(interfaces, []string{"^ens1$", "^ens2$", "ens"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := []PatternMatch{
+		{Pattern: "^ens1$", Found: 1, Up: 1, NotUp: []string{}},
+		{Pattern: "^ens2$", Found: 0, Up: 0, NotUp: []string{}},
+		{Pattern: "ens", Found: 2, Up: 1, NotUp: []string{"ens3"}},
+	}
+
+	if !reflect.DeepEqual(expected, matches) {
+		t.Fatalf("expected %v, got %v", expected, matches)
+	}
+}
+
+func TestVanished(t *testing.T) {
+	if !vanished("eth0", fmt.Errorf("could not read: %w", syscall.ENODEV)) {
+		t.Fatalf("expected %v, got %v", true, false)
+	}
+
+	if !vanished("csb-does-not-exist", errors.New("no such network interface")) {
+		t.Fatalf("expected %v, got %v", true, false)
+	}
+
+	if vanished("lo", errors.New("permission denied")) {
+		t.Fatalf("expected %v, got %v", false, true)
+	}
+}
+
+func TestGetInterfaceKind(t *testing.T) {
+	testCases := map[string]string{
+		"lo":       KindLoopback,
+		"eno1":     KindPhysical,
+		"wlp1s0":   KindWireless,
+		"br0":      KindBridge,
+		"bond0":    KindBond,
+		"eno1.10":  KindVlan,
+		"veth1234": KindVirtual,
+	}
+
+	for name, expected := range testCases {
+		kind := getInterfaceKind(path.Join("testdata/sys/class/net", name))
+		if kind != expected {
+			t.Fatalf("expected %v for %v, got %v", expected, name, kind)
+		}
+	}
+}
+
+func TestFilterInterfacesByType(t *testing.T) {
+	interfaces := []IfaceData{
+		{Name: "eno1", Kind: KindPhysical},
+		{Name: "docker0", Kind: KindBridge},
+		{Name: "veth1234", Kind: KindVirtual},
+	}
+
+	result, err := FilterInterfaces(&interfaces, &Filter{IncludeInterfaceTypes: []string{KindPhysical}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(result) != 1 || result[0].Name != "eno1" {
+		t.Fatalf("expected eno1, got %v", result)
+	}
+
+	result, err = FilterInterfaces(&interfaces, &Filter{ExcludeInterfaceTypes: []string{KindBridge, KindVirtual}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(result) != 1 || result[0].Name != "eno1" {
+		t.Fatalf("expected eno1, got %v", result)
+	}
+}
+
+func TestGetInterfaceLink(t *testing.T) {
+	iface := IfaceData{Name: "eno1"}
+	getInterfaceLink("testdata/sys/class/net/eno1", &iface)
+
+	if iface.Duplex != DuplexHalf || iface.MTU != 9000 || !iface.Carrier || iface.CarrierChanges != 7 {
+		t.Fatalf("expected half duplex, MTU 9000, carrier and 7 carrier changes, got %v", iface)
+	}
+
+	// Virtual interfaces have no duplex and no carrier while they are down
+	iface = IfaceData{Name: "veth1234"}
+	getInterfaceLink("testdata/sys/class/net/veth1234", &iface)
+
+	if iface.Duplex != DuplexUnknown || iface.MTU != 1500 || iface.Carrier {
+		t.Fatalf("expected unknown duplex, MTU 1500 and no carrier, got %v", iface)
+	}
+}
+
+func TestExpectations(t *testing.T) {
+	expectations := Expectations{}
+
+	err := expectations.Set("^ens=1500")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	err = expectations.Set("^ens1$=9000")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if expectations.Set("^ens") == nil || expectations.Set("^ens=fast") == nil {
+		t.Fatalf("expected an error for an invalid expectation")
+	}
+
+	value, ok := expectations.Lookup("ens1")
+	if !ok || value != 9000 {
+		t.Fatalf("expected %v, got %v", 9000, value)
+	}
+
+	value, ok = expectations.Lookup("ens2")
+	if !ok || value != 1500 {
+		t.Fatalf("expected %v, got %v", 1500, value)
+	}
+
+	_, ok = expectations.Lookup("eth0")
+	if ok {
+		t.Fatalf("expected no expectation for eth0")
+	}
+}
+
+func TestStateEnabled(t *testing.T) {
+	config := CheckConfig{}
+
+	if config.StateEnabled() {
+		t.Fatalf("expected %v, got %v", false, true)
+	}
+
+	err := config.WarningStatisticRate.Set("rx_fifo_errors=10")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !config.StateEnabled() {
+		t.Fatalf("expected %v, got %v", true, false)
+	}
+
+	config = CheckConfig{}
+
+	err = config.CarrierChanges.Crit.Set("5")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !config.StateEnabled() {
+		t.Fatalf("expected %v, got %v", true, false)
+	}
+}
+
+func TestLinkEnabled(t *testing.T) {
+	config := CheckConfig{}
+
+	if config.LinkEnabled() {
+		t.Fatalf("expected %v, got %v", false, true)
+	}
+
+	err := config.MTU.Set("^ens=9000")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !config.LinkEnabled() {
+		t.Fatalf("expected %v, got %v", true, false)
+	}
+
+	config = CheckConfig{FullDuplex: []string{"^ens"}}
+
+	if !config.LinkEnabled() {
+		t.Fatalf("expected %v, got %v", true, false)
+	}
+}
@@ -0,0 +1,17 @@
+package raid
+
+import (
+	"github.com/NETWAYS/check_system_basics/internal/common/thresholds"
+)
+
+type CheckConfig struct {
+	// Number of sectors found to differ by the last check or repair
+	MismatchCount thresholds.Thresholds
+
+	Filters Filter
+}
+
+type Filter struct {
+	IncludeArrayNames []string
+	ExcludeArrayNames []string
+}
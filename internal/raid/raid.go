@@ -0,0 +1,400 @@
+package raid
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NETWAYS/check_system_basics/internal/common/filter"
+)
+
+const (
+	mdstatPath = "/proc/mdstat"
+	sysPath    = "/sys"
+)
+
+// The sync actions of an array, as in /sys/block/<array>/md/sync_action
+const (
+	ActionIdle    = "idle"
+	ActionResync  = "resync"
+	ActionRecover = "recover"
+	ActionReshape = "reshape"
+	ActionCheck   = "check"
+	ActionRepair  = "repair"
+	ActionFrozen  = "frozen"
+)
+
+// The states of a member, as in /sys/block/<array>/md/dev-<member>/state
+const (
+	MemberInSync      = "in_sync"
+	MemberFaulty      = "faulty"
+	MemberSpare       = "spare"
+	MemberWriteMostly = "write_mostly"
+	MemberReplacement = "replacement"
+)
+
+// mdstatActions maps the names of the actions in /proc/mdstat to the names in sysfs
+var mdstatActions = map[string]string{
+	"resync":   ActionResync,
+	"recovery": ActionRecover,
+	"reshape":  ActionReshape,
+	"check":    ActionCheck,
+	"repair":   ActionRepair,
+}
+
+// mdstatMemberFlags maps the flags of members in /proc/mdstat to the member states
+var mdstatMemberFlags = map[byte]string{
+	'F': MemberFaulty,
+	'S': MemberSpare,
+	'W': MemberWriteMostly,
+	'R': MemberReplacement,
+}
+
+var (
+	memberPattern   = regexp.MustCompile(`^(\S+)\[(\d+)\]((?:\([A-Z]\))*)$`)
+	devicesPattern  = regexp.MustCompile(`\[(\d+)/(\d+)\]`)
+	progressPattern = regexp.MustCompile(`(resync|recovery|reshape|check|repair)\s*=\s*([\d.]+)%`)
+	finishPattern   = regexp.MustCompile(`finish=([\d.]+)min`)
+	speedPattern    = regexp.MustCompile(`speed=(\d+)K/sec`)
+)
+
+// Member is a component device of an array
+type Member struct {
+	Name string
+	// Slot is the role of the member in the array, it is -1 for spares in sysfs
+	Slot   int
+	States []string
+}
+
+// Faulty returns true if the member failed
+func (m *Member) Faulty() bool {
+	return slices.Contains(m.States, MemberFaulty)
+}
+
+// Array is a software RAID (md) array
+type Array struct {
+	Name   string
+	Active bool
+	// Level is e.g. raid1, it is empty for inactive arrays
+	Level string
+	// ArrayState is e.g. clean or active, it is empty if sysfs is not available
+	ArrayState string
+	// Devices is the number of devices the array should have, ActiveDevices the number it has
+	Devices       int
+	ActiveDevices int
+	// Degraded is the number of missing devices
+	Degraded int
+	Members  []Member
+
+	// SyncAction is the running action, e.g. recover for a rebuild
+	SyncAction string
+	// Progress of the sync action in percent, Finish is the estimated remaining time and Speed in KiB per second
+	Progress float64
+	Finish   time.Duration
+	Speed    uint64
+
+	// MismatchCount is nil if it is not available (e.g. for raid0)
+	MismatchCount *uint64
+}
+
+// FaultyMembers returns the names of the failed members
+func (a *Array) FaultyMembers() []string {
+	result := make([]string, 0)
+
+	for i := range a.Members {
+		if a.Members[i].Faulty() {
+			result = append(result, a.Members[i].Name)
+		}
+	}
+
+	return result
+}
+
+// Rebuilding returns true if the redundancy of the array is being restored or changed
+func (a *Array) Rebuilding() bool {
+	switch a.SyncAction {
+	case ActionResync, ActionRecover, ActionReshape:
+		return true
+	default:
+		return false
+	}
+}
+
+const (
+	ArrayName = iota
+)
+
+func (a Array) GetFilterableValue(ident uint) string {
+	switch ident {
+	case ArrayName:
+		return a.Name
+	default:
+		return ""
+	}
+}
+
+// GetAllArrays reads the arrays from /proc/mdstat and completes them with the details from sysfs
+func GetAllArrays() ([]Array, error) {
+	return getArrays(mdstatPath, sysPath)
+}
+
+func getArrays(mdstatPath, sysPath string) ([]Array, error) {
+	arrays, err := parseMdstat(mdstatPath)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range arrays {
+		err = readSysfs(filepath.Join(sysPath, "block", arrays[i].Name, "md"), &arrays[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return arrays, nil
+}
+
+// parseMdstat reads the arrays from /proc/mdstat, the result is empty if the md module is not loaded
+func parseMdstat(path string) ([]Array, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []Array{}, nil
+		}
+
+		return nil, err
+	}
+	defer file.Close()
+
+	arrays := make([]Array, 0)
+	scanner := bufio.NewScanner(file)
+
+	var current *Array
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// The details of an array are indented below its first line
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			if current != nil {
+				err = parseMdstatDetails(line, current)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			continue
+		}
+
+		name, description, found := strings.Cut(line, " : ")
+		if !found || !strings.HasPrefix(name, "md") {
+			current = nil
+			continue
+		}
+
+		array, err := parseMdstatArray(strings.TrimSpace(name), description)
+		if err != nil {
+			return nil, err
+		}
+
+		arrays = append(arrays, array)
+		current = &arrays[len(arrays)-1]
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return arrays, nil
+}
+
+// parseMdstatArray parses the first line of an array, e.g. "active raid1 sdb1[1](F) sda1[0]"
+func parseMdstatArray(name, description string) (Array, error) {
+	array := Array{Name: name, Progress: -1}
+
+	for i, field := range strings.Fields(description) {
+		switch {
+		case i == 0:
+			array.Active = field == "active"
+		case strings.HasPrefix(field, "("):
+			// e.g. (auto-read-only)
+			continue
+		case strings.HasPrefix(field, "raid") || field == "linear" || field == "multipath" || field == "faulty":
+			array.Level = field
+		default:
+			match := memberPattern.FindStringSubmatch(field)
+			if match == nil {
+				return array, fmt.Errorf("could not parse member %q of %s", field, name)
+			}
+
+			slot, _ := strconv.Atoi(match[2])
+
+			member := Member{Name: match[1], Slot: slot}
+
+			for j := 1; j < len(match[3]); j += 3 {
+				member.States = append(member.States, mdstatMemberFlags[match[3][j]])
+			}
+
+			if len(member.States) == 0 {
+				member.States = []string{MemberInSync}
+			}
+
+			array.Members = append(array.Members, member)
+		}
+	}
+
+	array.Devices = len(array.Members)
+	array.ActiveDevices = array.Devices
+
+	return array, nil
+}
+
+// parseMdstatDetails parses the status lines below an array, e.g. "[2/1] [U_]" or "recovery = 8.6% ... finish=0.5min speed=30293K/sec"
+func parseMdstatDetails(line string, array *Array) error {
+	if match := devicesPattern.FindStringSubmatch(line); match != nil {
+		array.Devices, _ = strconv.Atoi(match[1])
+		array.ActiveDevices, _ = strconv.Atoi(match[2])
+		array.Degraded = array.Devices - array.ActiveDevices
+	}
+
+	match := progressPattern.FindStringSubmatch(line)
+	if match == nil {
+		return nil
+	}
+
+	var err error
+
+	array.SyncAction = mdstatActions[match[1]]
+
+	array.Progress, err = strconv.ParseFloat(match[2], 64)
+	if err != nil {
+		return fmt.Errorf("could not parse progress of %s: %w", array.Name, err)
+	}
+
+	if finish := finishPattern.FindStringSubmatch(line); finish != nil {
+		minutes, err := strconv.ParseFloat(finish[1], 64)
+		if err != nil {
+			return fmt.Errorf("could not parse finish time of %s: %w", array.Name, err)
+		}
+
+		array.Finish = time.Duration(minutes * float64(time.Minute))
+	}
+
+	if speed := speedPattern.FindStringSubmatch(line); speed != nil {
+		array.Speed, err = strconv.ParseUint(speed[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("could not parse speed of %s: %w", array.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// readSysfs completes the array with the details from /sys/block/<array>/md, which are more
+// precise than /proc/mdstat. Missing entries (e.g. no mismatch_cnt for raid0) are skipped
+func readSysfs(path string, array *Array) error {
+	_, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+
+		return err
+	}
+
+	if value, ok, err := readSysfsValue(filepath.Join(path, "array_state")); err != nil {
+		return err
+	} else if ok {
+		array.ArrayState = value
+	}
+
+	if value, ok, err := readSysfsValue(filepath.Join(path, "sync_action")); err != nil {
+		return err
+	} else if ok {
+		array.SyncAction = value
+	}
+
+	if value, ok, err := readSysfsValue(filepath.Join(path, "degraded")); err != nil {
+		return err
+	} else if ok {
+		array.Degraded, err = strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("could not parse degraded of %s: %w", array.Name, err)
+		}
+	}
+
+	if value, ok, err := readSysfsValue(filepath.Join(path, "mismatch_cnt")); err != nil {
+		return err
+	} else if ok {
+		count, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("could not parse mismatch_cnt of %s: %w", array.Name, err)
+		}
+
+		array.MismatchCount = &count
+	}
+
+	for i := range array.Members {
+		value, ok, err := readSysfsValue(filepath.Join(path, "dev-"+array.Members[i].Name, "state"))
+		if err != nil {
+			return err
+		}
+
+		if ok && value != "" {
+			array.Members[i].States = strings.Split(value, ",")
+		}
+	}
+
+	return nil
+}
+
+// readSysfsValue returns the content of a sysfs file and false if it does not exist
+func readSysfsValue(path string) (string, bool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", false, nil
+		}
+
+		return "", false, err
+	}
+
+	return strings.TrimSpace(string(content)), true, nil
+}
+
+func FilterArrays(arrays []Array, filters *Filter) ([]Array, error) {
+	result, err := filter.Filter(arrays,
+		&filters.IncludeArrayNames,
+		ArrayName,
+		filter.Options{
+			MatchIncludedInResult: true,
+			RegexpMatching:        true,
+			EmptyFilterNoMatch:    false,
+		},
+	)
+	if err != nil {
+		return []Array{}, err
+	}
+
+	result, err = filter.Filter(result,
+		&filters.ExcludeArrayNames,
+		ArrayName,
+		filter.Options{
+			MatchIncludedInResult: false,
+			RegexpMatching:        true,
+			EmptyFilterNoMatch:    false,
+		},
+	)
+	if err != nil {
+		return []Array{}, err
+	}
+
+	return result, nil
+}
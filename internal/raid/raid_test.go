@@ -0,0 +1,91 @@
+package raid
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetArrays(t *testing.T) {
+	arrays, err := getArrays("testdata/mdstat", "testdata/sys")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(arrays) != 5 {
+		t.Fatalf("expected %v, got %v", 5, len(arrays))
+	}
+
+	rebuild := arrays[0]
+	if rebuild.Name != "md2" || rebuild.Level != "raid5" || !rebuild.Rebuilding() || rebuild.Degraded != 1 {
+		t.Fatalf("expected %v, got %v", "md2 rebuilding", rebuild)
+	}
+
+	if rebuild.Progress != 8.6 || rebuild.Finish != 30*time.Second || rebuild.Speed != 30293 {
+		t.Fatalf("expected %v, got %v %v %v", "8.6% 30s 30293", rebuild.Progress, rebuild.Finish, rebuild.Speed)
+	}
+
+	degraded := arrays[1]
+	if degraded.Devices != 2 || degraded.ActiveDevices != 1 || degraded.Rebuilding() {
+		t.Fatalf("expected %v, got %v", "md1 degraded", degraded)
+	}
+
+	faulty := degraded.FaultyMembers()
+	if len(faulty) != 1 || faulty[0] != "sdf2" {
+		t.Fatalf("expected %v, got %v", "[sdf2]", faulty)
+	}
+
+	// The states from sysfs replace the flags from mdstat
+	if len(degraded.Members[0].States) != 2 {
+		t.Fatalf("expected %v, got %v", "faulty,write_error", degraded.Members[0].States)
+	}
+
+	clean := arrays[2]
+	if !clean.Active || clean.Level != "raid1" || len(clean.Members) != 3 || clean.Degraded != 0 {
+		t.Fatalf("expected %v, got %v", "md0 clean", clean)
+	}
+
+	if clean.MismatchCount == nil || *clean.MismatchCount != 256 {
+		t.Fatalf("expected %v, got %v", 256, clean.MismatchCount)
+	}
+
+	if clean.Members[2].States[0] != MemberSpare {
+		t.Fatalf("expected %v, got %v", MemberSpare, clean.Members[2].States)
+	}
+
+	inactive := arrays[3]
+	if inactive.Active || inactive.Level != "" || len(inactive.Members) != 1 {
+		t.Fatalf("expected %v, got %v", "md127 inactive", inactive)
+	}
+
+	stripe := arrays[4]
+	if stripe.MismatchCount != nil || stripe.Degraded != 0 || stripe.Progress != -1 {
+		t.Fatalf("expected %v, got %v", "md3 without mismatch_cnt", stripe)
+	}
+}
+
+func TestGetArraysWithoutMd(t *testing.T) {
+	arrays, err := getArrays("testdata/missing", "testdata/sys")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(arrays) != 0 {
+		t.Fatalf("expected no arrays, got %v", arrays)
+	}
+}
+
+func TestFilterArrays(t *testing.T) {
+	arrays, err := getArrays("testdata/mdstat", "testdata/sys")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	filtered, err := FilterArrays(arrays, &Filter{ExcludeArrayNames: []string{"^md12"}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(filtered) != 4 {
+		t.Fatalf("expected %v, got %v", 4, len(filtered))
+	}
+}
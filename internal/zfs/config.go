@@ -0,0 +1,20 @@
+package zfs
+
+import (
+	"github.com/NETWAYS/check_system_basics/internal/common/thresholds"
+)
+
+type CheckConfig struct {
+	// Allocated space of a pool in percent of its size
+	Capacity thresholds.Thresholds
+
+	// The zpool command used to determine the capacity, the capacity is not checked if it is empty
+	ZpoolCommand string
+
+	Filters Filter
+}
+
+type Filter struct {
+	IncludePoolNames []string
+	ExcludePoolNames []string
+}
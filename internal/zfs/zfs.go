@@ -0,0 +1,364 @@
+package zfs
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/NETWAYS/check_system_basics/internal/common/filter"
+)
+
+const kstatPath = "/proc/spl/kstat/zfs"
+
+// The states of a pool
+const (
+	StateOnline   = "ONLINE"
+	StateDegraded = "DEGRADED"
+	StateFaulted  = "FAULTED"
+	StateOffline  = "OFFLINE"
+	StateUnavail  = "UNAVAIL"
+	StateRemoved  = "REMOVED"
+	StateSuspend  = "SUSPENDED"
+)
+
+// IOStats are the I/O counters of a pool since it was imported
+type IOStats struct {
+	ReadBytes    uint64
+	WrittenBytes uint64
+	Reads        uint64
+	Writes       uint64
+}
+
+// Capacity is the size of a pool as reported by zpool list
+type Capacity struct {
+	Size      uint64
+	Allocated uint64
+	Free      uint64
+}
+
+// UsedPercent returns the allocated space in percent of the size
+func (c *Capacity) UsedPercent() float64 {
+	if c.Size == 0 {
+		return 0
+	}
+
+	return float64(c.Allocated) / float64(c.Size) * 100
+}
+
+// Pool describes an imported ZFS pool
+type Pool struct {
+	Name  string
+	State string
+	// IO is nil if the kernel module does not provide I/O statistics for the pool
+	IO *IOStats
+	// Capacity is nil if it was not determined
+	Capacity *Capacity
+}
+
+const (
+	PoolName = iota
+)
+
+func (p Pool) GetFilterableValue(ident uint) string {
+	switch ident {
+	case PoolName:
+		return p.Name
+	default:
+		return ""
+	}
+}
+
+// ARCStats are the most important statistics of the adaptive replacement cache
+type ARCStats struct {
+	Size   uint64
+	Target uint64
+	Max    uint64
+	Hits   uint64
+	Misses uint64
+}
+
+// HitRatio returns the percentage of hits since the module was loaded
+func (a *ARCStats) HitRatio() float64 {
+	if a.Hits+a.Misses == 0 {
+		return 0
+	}
+
+	return float64(a.Hits) / float64(a.Hits+a.Misses) * 100
+}
+
+// GetAllPools returns the imported pools with their state and I/O statistics
+func GetAllPools() ([]Pool, error) {
+	return getPools(kstatPath)
+}
+
+func getPools(basePath string) ([]Pool, error) {
+	entries, err := os.ReadDir(basePath)
+	if err != nil {
+		// The zfs module is not loaded, so there are no pools
+		if errors.Is(err, os.ErrNotExist) {
+			return []Pool{}, nil
+		}
+
+		return []Pool{}, err
+	}
+
+	result := make([]Pool, 0)
+
+	for _, entry := range entries {
+		// Every pool has its own directory, the other entries are global statistics
+		if !entry.IsDir() {
+			continue
+		}
+
+		pool, err := readPool(path.Join(basePath, entry.Name()), entry.Name())
+		if err != nil {
+			return []Pool{}, err
+		}
+
+		result = append(result, pool)
+	}
+
+	return result, nil
+}
+
+func readPool(poolPath, name string) (Pool, error) {
+	pool := Pool{Name: name}
+
+	// The state is available since ZFS on Linux 0.8
+	state, err := os.ReadFile(path.Join(poolPath, "state"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return pool, fmt.Errorf("could not read the state of pool %s: %w", name, err)
+	}
+
+	pool.State = strings.TrimSpace(string(state))
+
+	pool.IO, err = readPoolIO(poolPath)
+	if err != nil {
+		return pool, fmt.Errorf("could not read the I/O statistics of pool %s: %w", name, err)
+	}
+
+	return pool, nil
+}
+
+// readPoolIO reads the io kstat of a pool. Newer versions of OpenZFS do not provide it,
+// then the statistics of all datasets (objset-*) are summed up
+func readPoolIO(poolPath string) (*IOStats, error) {
+	file, err := os.Open(path.Join(poolPath, "io"))
+	if err == nil {
+		defer file.Close()
+
+		return parseIOKstat(file)
+	}
+
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(poolPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var stats *IOStats
+
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), "objset-") {
+			continue
+		}
+
+		values, err := readNamedKstat(path.Join(poolPath, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		if stats == nil {
+			stats = &IOStats{}
+		}
+
+		stats.ReadBytes += parseUint(values["nread"])
+		stats.WrittenBytes += parseUint(values["nwritten"])
+		stats.Reads += parseUint(values["reads"])
+		stats.Writes += parseUint(values["writes"])
+	}
+
+	return stats, nil
+}
+
+// parseIOKstat parses a kstat of the I/O type, which consists of a header line,
+// a line with the names of the values and a line with the values
+func parseIOKstat(reader io.Reader) (*IOStats, error) {
+	scanner := bufio.NewScanner(reader)
+
+	lines := make([][]string, 0, 3)
+
+	for scanner.Scan() {
+		lines = append(lines, strings.Fields(scanner.Text()))
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(lines) < 3 || len(lines[1]) != len(lines[2]) {
+		return nil, errors.New("unexpected format of the io kstat")
+	}
+
+	values := make(map[string]string, len(lines[1]))
+
+	for i := range lines[1] {
+		values[lines[1][i]] = lines[2][i]
+	}
+
+	return &IOStats{
+		ReadBytes:    parseUint(values["nread"]),
+		WrittenBytes: parseUint(values["nwritten"]),
+		Reads:        parseUint(values["reads"]),
+		Writes:       parseUint(values["writes"]),
+	}, nil
+}
+
+// readNamedKstat reads a kstat of the named type, which consists of a header line,
+// the line "name type data" and one line per value
+func readNamedKstat(kstatFile string) (map[string]string, error) {
+	file, err := os.Open(kstatFile)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	line := 0
+
+	for scanner.Scan() {
+		line++
+
+		if line <= 2 {
+			continue
+		}
+
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 {
+			continue
+		}
+
+		values[fields[0]] = strings.Join(fields[2:], " ")
+	}
+
+	return values, scanner.Err()
+}
+
+func parseUint(value string) uint64 {
+	result, _ := strconv.ParseUint(value, 10, 64)
+	return result
+}
+
+// GetARCStats reads the statistics of the ARC, it returns nil if the zfs module is not loaded
+func GetARCStats() (*ARCStats, error) {
+	return getARCStats(kstatPath)
+}
+
+func getARCStats(basePath string) (*ARCStats, error) {
+	values, err := readNamedKstat(path.Join(basePath, "arcstats"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &ARCStats{
+		Size:   parseUint(values["size"]),
+		Target: parseUint(values["c"]),
+		Max:    parseUint(values["c_max"]),
+		Hits:   parseUint(values["hits"]),
+		Misses: parseUint(values["misses"]),
+	}, nil
+}
+
+// GetCapacities determines the capacity of the pools with the zpool command, as it is not part of the kstats.
+// The health reported by zpool is used as state for pools without state in the kstats
+func GetCapacities(ctx context.Context, zpoolCommand string, pools []Pool) error {
+	output, err := exec.CommandContext(ctx, zpoolCommand, "list", "-Hp", "-o", "name,size,allocated,free,health").Output()
+	if err != nil {
+		return fmt.Errorf("could not determine the capacity of the pools with %s: %w", zpoolCommand, err)
+	}
+
+	return parseZpoolList(strings.NewReader(string(output)), pools)
+}
+
+// parseZpoolList parses the output of "zpool list -Hp -o name,size,allocated,free,health"
+func parseZpoolList(reader io.Reader, pools []Pool) error {
+	scanner := bufio.NewScanner(reader)
+
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) != 5 {
+			return fmt.Errorf("unexpected output of zpool list: %q", scanner.Text())
+		}
+
+		capacity := Capacity{}
+
+		for i, field := range []*uint64{&capacity.Size, &capacity.Allocated, &capacity.Free} {
+			value, err := strconv.ParseUint(fields[i+1], 10, 64)
+			if err != nil {
+				return fmt.Errorf("unexpected output of zpool list: %w", err)
+			}
+
+			*field = value
+		}
+
+		for i := range pools {
+			if pools[i].Name != fields[0] {
+				continue
+			}
+
+			pools[i].Capacity = &capacity
+
+			if pools[i].State == "" {
+				pools[i].State = fields[4]
+			}
+		}
+	}
+
+	return scanner.Err()
+}
+
+func FilterPools(pools []Pool, filters *Filter) ([]Pool, error) {
+	result, err := filter.Filter(pools,
+		&filters.IncludePoolNames,
+		PoolName,
+		filter.Options{
+			MatchIncludedInResult: true,
+			RegexpMatching:        true,
+			EmptyFilterNoMatch:    false,
+		},
+	)
+	if err != nil {
+		return []Pool{}, err
+	}
+
+	result, err = filter.Filter(result,
+		&filters.ExcludePoolNames,
+		PoolName,
+		filter.Options{
+			MatchIncludedInResult: false,
+			RegexpMatching:        true,
+			EmptyFilterNoMatch:    false,
+		},
+	)
+	if err != nil {
+		return []Pool{}, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,113 @@
+package zfs
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestGetPools(t *testing.T) {
+	pools, err := getPools("testdata/kstat")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(pools) != 2 {
+		t.Fatalf("expected %v, got %v", 2, len(pools))
+	}
+
+	// The pools are sorted by name
+	backup, tank := pools[0], pools[1]
+
+	if backup.Name != "backup" || backup.State != StateDegraded {
+		t.Fatalf("expected %v %v, got %v %v", "backup", StateDegraded, backup.Name, backup.State)
+	}
+
+	expected := IOStats{ReadBytes: 245760, WrittenBytes: 491520, Reads: 60, Writes: 120}
+	if backup.IO == nil || !reflect.DeepEqual(expected, *backup.IO) {
+		t.Fatalf("expected %v, got %v", expected, backup.IO)
+	}
+
+	if tank.Name != "tank" || tank.State != StateOnline {
+		t.Fatalf("expected %v %v, got %v %v", "tank", StateOnline, tank.Name, tank.State)
+	}
+
+	expected = IOStats{ReadBytes: 1843802624, WrittenBytes: 1346621440, Reads: 87634, Writes: 143590}
+	if tank.IO == nil || !reflect.DeepEqual(expected, *tank.IO) {
+		t.Fatalf("expected %v, got %v", expected, tank.IO)
+	}
+}
+
+func TestGetPoolsWithoutZFS(t *testing.T) {
+	pools, err := getPools("testdata/missing")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(pools) != 0 {
+		t.Fatalf("expected no pools, got %v", pools)
+	}
+
+	arc, err := getARCStats("testdata/missing")
+	if err != nil || arc != nil {
+		t.Fatalf("expected no ARC statistics, got %v (%v)", arc, err)
+	}
+}
+
+func TestGetARCStats(t *testing.T) {
+	arc, err := getARCStats("testdata/kstat")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := ARCStats{Size: 4026531840, Target: 4294967296, Max: 8589934592, Hits: 9000, Misses: 1000}
+	if !reflect.DeepEqual(expected, *arc) {
+		t.Fatalf("expected %v, got %v", expected, *arc)
+	}
+
+	if arc.HitRatio() != 90 {
+		t.Fatalf("expected %v, got %v", 90, arc.HitRatio())
+	}
+}
+
+func TestParseZpoolList(t *testing.T) {
+	file, err := os.Open("testdata/zpool_list")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer file.Close()
+
+	pools := []Pool{{Name: "tank", State: StateOnline}, {Name: "backup"}}
+
+	err = parseZpoolList(file, pools)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := Capacity{Size: 1000204886016, Allocated: 200040977203, Free: 800163908813}
+	if pools[0].Capacity == nil || !reflect.DeepEqual(expected, *pools[0].Capacity) {
+		t.Fatalf("expected %v, got %v", expected, pools[0].Capacity)
+	}
+
+	// The health of zpool list is used for pools without state in the kstats
+	if pools[1].State != StateDegraded {
+		t.Fatalf("expected %v, got %v", StateDegraded, pools[1].State)
+	}
+
+	if used := pools[1].Capacity.UsedPercent(); used < 89.9 || used > 90.1 {
+		t.Fatalf("expected about %v, got %v", 90, used)
+	}
+}
+
+func TestFilterPools(t *testing.T) {
+	pools := []Pool{{Name: "tank"}, {Name: "backup"}, {Name: "tank2"}}
+
+	result, err := FilterPools(pools, &Filter{IncludePoolNames: []string{"^tank"}, ExcludePoolNames: []string{"2$"}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(result) != 1 || result[0].Name != "tank" {
+		t.Fatalf("expected only tank, got %v", result)
+	}
+}